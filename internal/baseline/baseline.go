@@ -0,0 +1,169 @@
+// Package baseline records known broken links so that only new ones fail the check.
+package baseline
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+const FileName = ".relcheck-baseline.json"
+
+// Entry is a known broken link, keyed by file, URL and reason so that it survives lines moving around
+type Entry struct {
+	File   string `json:"file"` // Relative to the directory of the baseline file, wherever relcheck runs
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+	Count  int    `json:"count"` // Occurrences of the same link within the file
+}
+
+type document struct {
+	Entries []Entry `json:"entries"`
+}
+
+type key struct {
+	file   string
+	url    string
+	reason string
+}
+
+func newKey(file, url, reason string) key {
+	return key{file: filepath.ToSlash(filepath.Clean(file)), url: url, reason: reason}
+}
+
+// relativeTo makes a file given relative to the working directory relative to dir instead
+func relativeTo(dir, file string) string {
+	absolute, err := filepath.Abs(file)
+	if err != nil {
+		return file
+	}
+
+	relative, err := filepath.Rel(dir, absolute)
+	if err != nil {
+		return file
+	}
+
+	return relative
+}
+
+// directoryOf returns the absolute directory of the baseline file at path, which entries are relative to
+func directoryOf(path string) string {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Dir(path)
+	}
+
+	return filepath.Dir(absolute)
+}
+
+// Baseline holds the known broken links, each of which is used up once matched
+type Baseline struct {
+	mu        sync.Mutex // Files are checked in parallel
+	dir       string     // Of the baseline file
+	remaining map[key]int
+}
+
+func Empty() *Baseline {
+	return &Baseline{mu: sync.Mutex{}, dir: ".", remaining: make(map[key]int)}
+}
+
+func Load(path string) (*Baseline, error) {
+	doc, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	baseline := Empty()
+	baseline.dir = directoryOf(path)
+
+	for _, entry := range doc.Entries {
+		baseline.remaining[newKey(entry.File, entry.URL, entry.Reason)] += max(entry.Count, 1)
+	}
+
+	return baseline, nil
+}
+
+// Known tells whether the broken link is in the baseline, using up one occurrence of it
+func (b *Baseline) Known(file, url, reason string) bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	k := newKey(relativeTo(b.dir, file), url, reason)
+	if b.remaining[k] == 0 {
+		return false
+	}
+
+	b.remaining[k]--
+
+	return true
+}
+
+// Fixed returns the entries of the checked files that no broken link matched, with their files as given
+func (b *Baseline) Fixed(files []string) []Entry {
+	if b == nil {
+		return []Entry{}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	checked := make(map[string]string, len(files))
+	for _, file := range files {
+		checked[newKey(relativeTo(b.dir, file), "", "").file] = file
+	}
+
+	fixed := []Entry{}
+
+	for k, count := range b.remaining {
+		if file, ok := checked[k.file]; ok && count > 0 {
+			fixed = append(fixed, Entry{File: file, URL: k.url, Reason: k.reason, Count: count})
+		}
+	}
+
+	sortEntries(fixed)
+
+	return fixed
+}
+
+func sortEntries(entries []Entry) {
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return cmp.Or(cmp.Compare(a.File, b.File), cmp.Compare(a.URL, b.URL), cmp.Compare(a.Reason, b.Reason))
+	})
+}
+
+func read(path string) (document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return document{}, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	var doc document
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return document{}, fmt.Errorf("invalid baseline %s: %w", path, err)
+	}
+
+	return doc, nil
+}
+
+func write(path string, entries []Entry) error {
+	sortEntries(entries)
+
+	content, err := json.MarshalIndent(document{Entries: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode baseline: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(content, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package baseline
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/rule"
+)
+
+// Recorder is a reporter that writes every broken link to a baseline file on Flush
+type Recorder struct {
+	path   string
+	dir    string // Of the baseline file, which entries are relative to
+	counts map[key]int
+	kept   []Entry // Of files that are not checked again
+	failed bool
+	output io.Writer
+}
+
+func NewRecorder(path string, output io.Writer) *Recorder {
+	return &Recorder{
+		path:   path,
+		dir:    directoryOf(path),
+		counts: make(map[key]int),
+		kept:   []Entry{},
+		failed: false,
+		output: output,
+	}
+}
+
+// Keep reads the existing baseline file, if any, to keep the entries of files other than the given ones,
+// so that recording a few files leaves the rest of the baseline as it was. Entries of deleted files are dropped.
+func (r *Recorder) Keep(files []string) error {
+	if _, err := os.Stat(r.path); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	doc, err := read(r.path)
+	if err != nil {
+		return err
+	}
+
+	checked := make(map[string]bool, len(files))
+	for _, file := range files {
+		checked[newKey(relativeTo(r.dir, file), "", "").file] = true
+	}
+
+	for _, entry := range doc.Entries {
+		file := newKey(entry.File, "", "").file
+		if checked[file] {
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(r.dir, filepath.FromSlash(file))); err == nil {
+			r.kept = append(r.kept, entry)
+		}
+	}
+
+	return nil
+}
+
+// Failed tells whether a file could not be checked, which a baseline cannot cover
+func (r *Recorder) Failed() bool {
+	return r.failed
+}
+
+func (r *Recorder) FileNotFound(filename string) {
+	r.failed = true
+	fmt.Fprintf(r.output, "Error: File not found: %s\n", filename)
+}
+
+func (r *Recorder) ScanError(filename string, err error) {
+	r.failed = true
+	fmt.Fprintf(r.output, "Error: Could not process file %s: %v\n", filename, err)
+}
+
+func (r *Recorder) NoLinks(_ string) {}
+
+func (r *Recorder) ValidLink(_ string, _ link.Link) {}
+
+func (r *Recorder) BrokenLink(filename string, brokenLink link.Link, errorType string, _ string) {
+	// Warnings do not fail the check, so they need no baseline
+	if brokenLink.Severity == rule.Warning {
+		return
+	}
+
+	r.counts[newKey(relativeTo(r.dir, filename), brokenLink.URL, errorType)]++
+}
+
+func (r *Recorder) ValidLinks(_ string, _ int, _ bool) {}
+
+func (r *Recorder) Success() {}
+
+func (r *Recorder) Flush() error {
+	entries := make([]Entry, 0, len(r.counts)+len(r.kept))
+	total := 0
+
+	for k, count := range r.counts {
+		entries = append(entries, Entry{File: k.file, URL: k.url, Reason: k.reason, Count: count})
+		total += count
+	}
+
+	if err := write(r.path, append(entries, r.kept...)); err != nil {
+		return err
+	}
+
+	if len(r.kept) > 0 {
+		fmt.Fprintf(r.output, "Recorded %d broken links in %s, keeping %d entries of other files\n",
+			total, r.path, len(r.kept))
+	} else {
+		fmt.Fprintf(r.output, "Recorded %d broken links in %s\n", total, r.path)
+	}
+
+	return nil
+}
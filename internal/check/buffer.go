@@ -0,0 +1,83 @@
+package check
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/reporter"
+)
+
+// buffer holds the reports of one file until the files before it have been reported,
+// so that checking in parallel gives the same output as checking one file at a time
+type buffer struct {
+	reports []bufferedReport
+}
+
+type bufferedReport struct {
+	line   int // Of the link reported, 0 for reports about the whole file
+	column int
+	report func(reporter.Reporter)
+}
+
+func (b *buffer) replay(report reporter.Reporter) {
+	for _, r := range b.reports {
+		r.report(report)
+	}
+}
+
+// sortByPosition orders the reports by the position of their links within the file
+func (b *buffer) sortByPosition() {
+	slices.SortStableFunc(b.reports, func(x, y bufferedReport) int {
+		return cmp.Or(cmp.Compare(x.line, y.line), cmp.Compare(x.column, y.column))
+	})
+}
+
+func (b *buffer) add(r func(reporter.Reporter)) {
+	b.addAt(0, 0, r)
+}
+
+func (b *buffer) addAt(line, column int, r func(reporter.Reporter)) {
+	b.reports = append(b.reports, bufferedReport{line: line, column: column, report: r})
+}
+
+func (b *buffer) FileNotFound(filename string) {
+	b.add(func(report reporter.Reporter) { report.FileNotFound(filename) })
+}
+
+func (b *buffer) ScanError(filename string, err error) {
+	b.add(func(report reporter.Reporter) { report.ScanError(filename, err) })
+}
+
+func (b *buffer) NoLinks(filename string) {
+	b.add(func(report reporter.Reporter) { report.NoLinks(filename) })
+}
+
+func (b *buffer) ValidLink(filename string, validLink link.Link) {
+	b.addAt(validLink.Line, validLink.Column, func(report reporter.Reporter) { report.ValidLink(filename, validLink) })
+}
+
+func (b *buffer) BrokenLink(filename string, brokenLink link.Link, errorType string, lineContent string) {
+	b.addAt(brokenLink.Line, brokenLink.Column, func(report reporter.Reporter) {
+		report.BrokenLink(filename, brokenLink, errorType, lineContent)
+	})
+}
+
+func (b *buffer) KnownLink(filename string, knownLink link.Link, errorType string) {
+	b.addAt(knownLink.Line, knownLink.Column, func(report reporter.Reporter) {
+		if known, ok := report.(reporter.KnownLinkReporter); ok {
+			known.KnownLink(filename, knownLink, errorType)
+		}
+	})
+}
+
+func (b *buffer) ValidLinks(filename string, count int, hasBrokenLinks bool) {
+	b.add(func(report reporter.Reporter) { report.ValidLinks(filename, count, hasBrokenLinks) })
+}
+
+// Success and Flush concern the whole run, which only the real reporter sees
+func (b *buffer) Success() {}
+
+func (b *buffer) Flush() error {
+	return nil
+}
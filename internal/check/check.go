@@ -1,122 +1,412 @@
 package check
 
 import (
+	"cmp"
+	"context"
+	"fmt"
 	"net/url"
+	"os"
 	"regexp"
+	"runtime"
+	"strings"
 
+	"github.com/anttiharju/relcheck/internal/baseline"
 	"github.com/anttiharju/relcheck/internal/exitcode"
 	"github.com/anttiharju/relcheck/internal/fileutils"
 	"github.com/anttiharju/relcheck/internal/markdown/anchor"
 	"github.com/anttiharju/relcheck/internal/markdown/link"
 	"github.com/anttiharju/relcheck/internal/markdown/scan"
 	"github.com/anttiharju/relcheck/internal/reporter"
+	"github.com/anttiharju/relcheck/internal/rule"
 )
 
-func RelativeLinksAndAnchors(verbose, forceColors bool, files []string) exitcode.Exitcode {
-	report := reporter.New(verbose, forceColors)
-	exitCode := exitcode.Success
+type Options struct {
+	Root     string // Repository root for links such as /docs/setup.md, empty if unknown
+	Slugger  anchor.Slugger
+	Report   reporter.Reporter
+	Ignore   func(target string) bool // Whether links to a resolved target are not checked, nil to check all
+	Severity map[string]rule.Severity // By rule ID, error if missing
+	Baseline *baseline.Baseline       // Known broken links that do not fail the check, nil if none
+	Jobs     int                      // Files checked in parallel, GOMAXPROCS if zero
+	Cache    *scan.DiskCache          // Scan results of earlier runs, nil if disabled
+}
 
-	for _, filepath := range files {
-		fileExitCode := isFileValid(filepath, report)
-		if fileExitCode != exitcode.Success {
-			exitCode = fileExitCode
-		}
+type checker struct {
+	root     string
+	slugger  anchor.Slugger
+	report   reporter.Reporter
+	ignore   func(target string) bool
+	severity map[string]rule.Severity
+	baseline *baseline.Baseline
+	cache    *scan.DiskCache
+	suppress *suppressions // Of the file being checked
+}
+
+// suppressions tracks which suppression comments of a file were needed
+type suppressions struct {
+	comments []scan.Suppression
+	used     []bool
+}
+
+// status of a checked link
+type status int
+
+const (
+	valid  status = iota
+	warned        // Broken, but its rule is only a warning
+	broken
+	known // Broken, but in the baseline
+)
+
+func newChecker(opts Options) checker {
+	return checker{
+		root:     opts.Root,
+		slugger:  opts.Slugger,
+		report:   opts.Report,
+		ignore:   opts.Ignore,
+		severity: opts.Severity,
+		baseline: opts.Baseline,
+		cache:    opts.Cache,
+		suppress: nil,
+	}
+}
+
+func RelativeLinksAndAnchors(ctx context.Context, opts Options, files []string) exitcode.Exitcode {
+	report := opts.Report
+	c := newChecker(opts)
+
+	exitCode := c.areFilesValid(ctx, files, cmp.Or(opts.Jobs, runtime.GOMAXPROCS(0)))
+	if ctx.Err() != nil {
+		// The report would be incomplete
+		return exitcode.Interrupt
 	}
 
 	if exitCode == exitcode.Success {
 		report.Success()
 	}
 
+	if err := report.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+
+	reportFixed(opts.Baseline.Fixed(files))
+
 	return exitCode
 }
 
-func isFileValid(filepath string, report *reporter.Reporter) exitcode.Exitcode {
+// reportFixed lists baseline entries that can be removed, on stderr to keep machine-readable reports intact
+func reportFixed(fixed []baseline.Entry) {
+	if len(fixed) == 0 {
+		return
+	}
+
+	for _, entry := range fixed {
+		fmt.Fprintf(os.Stderr, "%s: fixed since the baseline: %s (%s)\n", entry.File, entry.URL, entry.Reason)
+	}
+
+	fmt.Fprintln(os.Stderr, "Run relcheck baseline to remove fixed links from the baseline.")
+}
+
+// Content checks the links of a file that may not be saved, such as an editor buffer, given its scan result.
+// Only the links are reported, neither Success nor Flush.
+func Content(opts Options, filepath string, scanResult scan.Result) exitcode.Exitcode {
+	return newChecker(opts).areLinksValid(filepath, scanResult)
+}
+
+// Target resolves the file or directory the path part of a link of a file points to
+func Target(root, filepath, path string) (string, bool) {
+	decodedPath, err := url.QueryUnescape(path)
+	if err != nil {
+		return "", false
+	}
+
+	return resolveTarget(root, filepath, decodedPath)
+}
+
+// areFilesValid checks files with a bounded number of workers, reporting them in the order they were given
+func (c checker) areFilesValid(ctx context.Context, files []string, jobs int) exitcode.Exitcode {
+	type fileResult struct {
+		buffer   *buffer
+		exitCode exitcode.Exitcode
+		done     chan struct{}
+	}
+
+	results := make([]fileResult, len(files))
+	for i := range results {
+		results[i] = fileResult{buffer: &buffer{reports: nil}, exitCode: exitcode.Success, done: make(chan struct{})}
+	}
+
+	indices := make(chan int, len(files))
+	for i := range files {
+		indices <- i
+	}
+
+	close(indices)
+
+	for range min(jobs, len(files)) {
+		go func() {
+			for i := range indices {
+				if ctx.Err() != nil {
+					close(results[i].done)
+
+					continue
+				}
+
+				fileChecker := c
+				fileChecker.report = results[i].buffer
+				results[i].exitCode = fileChecker.isFileValid(files[i])
+				close(results[i].done)
+			}
+		}()
+	}
+
+	exitCode := exitcode.Success
+
+	// Each file is reported as soon as it and the files before it are done
+	for i := range results {
+		<-results[i].done
+		results[i].buffer.replay(c.report)
+
+		if results[i].exitCode != exitcode.Success {
+			exitCode = results[i].exitCode
+		}
+	}
+
+	return exitCode
+}
+
+func (c checker) isFileValid(filepath string) exitcode.Exitcode {
+	report := c.report
+
 	if !fileutils.FileExists(filepath) {
 		report.FileNotFound(filepath)
 
 		return exitcode.BrokenLinks
 	}
 
-	scanResult, err := scan.File(filepath)
+	scanResult, err := scan.File(filepath, c.slugger, c.cache)
 	if err != nil {
 		report.ScanError(filepath, err)
 
 		return exitcode.BrokenLinks
 	}
 
-	if len(scanResult.Links) == 0 {
+	if len(scanResult.Links) == 0 && len(scanResult.UndefinedReferences) == 0 &&
+		len(scanResult.UnusedDefinitions) == 0 && len(scanResult.Suppressions) == 0 &&
+		len(scanResult.UnknownRules) == 0 {
 		report.NoLinks(filepath)
 
 		return exitcode.Success
 	}
 
-	return areLinksValid(filepath, scanResult, report)
+	return c.areLinksValid(filepath, scanResult)
 }
 
-func areLinksValid(filepath string, scanResult scan.Result, report *reporter.Reporter) exitcode.Exitcode {
-	brokenLinksFound := false
-	validLinksCount := 0
+func (c checker) areLinksValid(filepath string, scanResult scan.Result) exitcode.Exitcode {
+	// Findings are reported in the order they appear in the file, whichever check found them
+	report := c.report
+	findings := &buffer{reports: nil}
+	c.report = findings
+
+	statuses := make(map[status]int)
+	c.suppress = &suppressions{
+		comments: scanResult.Suppressions,
+		used:     make([]bool, len(scanResult.Suppressions)),
+	}
 
 	for _, link := range scanResult.Links {
-		valid := isLinkValid(filepath, link, report)
-		if valid {
-			validLinksCount++
-		} else {
-			brokenLinksFound = true
+		if c.isIgnored(filepath, link) {
+			continue
+		}
+
+		statuses[c.isLinkValid(filepath, link)]++
+	}
+
+	for _, reference := range scanResult.UndefinedReferences {
+		statuses[c.broken(filepath, reference, rule.UndefinedReference)]++
+	}
+
+	for _, definition := range scanResult.UnusedDefinitions {
+		statuses[c.broken(filepath, definition, rule.UnusedDefinition)]++
+	}
+
+	for _, unknownRule := range scanResult.UnknownRules {
+		statuses[c.broken(filepath, unknownRule, rule.UnknownRule)]++
+	}
+
+	// Suppressions that no longer hide anything should be removed
+	for i, suppression := range c.suppress.comments {
+		if !c.suppress.used[i] {
+			statuses[c.broken(filepath, suppressionLink(suppression), rule.UnusedSuppression)]++
 		}
 	}
 
-	report.ValidLinks(filepath, validLinksCount, brokenLinksFound)
+	findings.sortByPosition()
+	findings.replay(report)
+	report.ValidLinks(filepath, statuses[valid], statuses[warned]+statuses[broken] > 0)
 
-	if brokenLinksFound {
+	if statuses[broken] > 0 {
 		return exitcode.BrokenLinks
 	}
 
 	return exitcode.Success
 }
 
-func isLinkValid(filepath string, link link.Link, report *reporter.Reporter) bool {
-	decodedPath, err := url.QueryUnescape(link.Path)
+// isIgnored matches the target of a link rather than its path, which depends on where the link is
+func (c checker) isIgnored(filepath string, link link.Link) bool {
+	// Anchors within the same file are always checked
+	if c.ignore == nil || link.Path == "" {
+		return false
+	}
+
+	target, ok := Target(c.root, filepath, link.Path)
+
+	return ok && c.ignore(target)
+}
+
+// broken reports a broken link with the severity configured for its rule, unless a comment suppresses it
+func (c checker) broken(filepath string, link link.Link, brokenRule rule.Rule) status {
+	severity, ok := c.severity[brokenRule.ID]
+	if !ok {
+		severity = rule.Error
+	}
+
+	if c.isSuppressed(link, brokenRule) {
+		severity = rule.Off
+	}
+
+	if severity == rule.Error && c.baseline.Known(filepath, link.URL, brokenRule.Reason) {
+		if report, ok := c.report.(reporter.KnownLinkReporter); ok {
+			report.KnownLink(filepath, link, brokenRule.Reason)
+		}
+
+		return known
+	}
+
+	link.Severity = severity
+
+	switch severity {
+	case rule.Off:
+		c.report.ValidLink(filepath, link)
+
+		return valid
+	case rule.Warning:
+		c.report.BrokenLink(filepath, link, brokenRule.Reason, link.LineContent)
+
+		return warned
+	case rule.Error:
+	}
+
+	c.report.BrokenLink(filepath, link, brokenRule.Reason, link.LineContent)
+
+	return broken
+}
+
+func (c checker) isSuppressed(link link.Link, brokenRule rule.Rule) bool {
+	// Findings about suppression comments cannot be hidden by them
+	if c.suppress == nil || brokenRule == rule.UnusedSuppression || brokenRule == rule.UnknownRule {
+		return false
+	}
+
+	suppressed := false
+
+	for i, suppression := range c.suppress.comments {
+		if suppression.Covers(link.Line, brokenRule) {
+			c.suppress.used[i] = true
+			suppressed = true
+		}
+	}
+
+	return suppressed
+}
+
+// suppressionLink points at a suppression comment so that it can be reported like a link
+func suppressionLink(suppression scan.Suppression) link.Link {
+	return link.Link{
+		URL:         suppression.Directive,
+		Line:        suppression.Line,
+		Column:      suppression.Column,
+		EndColumn:   suppression.EndColumn,
+		Path:        "",
+		Anchor:      "",
+		Target:      "",
+		Fix:         "",
+		Severity:    "",
+		IsValid:     false,
+		LineContent: suppression.LineContent,
+	}
+}
+
+func (c checker) isLinkValid(filepath string, link link.Link) status {
+	report := c.report
+
+	// A query string such as ?raw=1 asks for another view of the same file
+	path, _, _ := strings.Cut(link.Path, "?")
+
+	decodedPath, err := url.QueryUnescape(path)
 	if err != nil {
 		report.ScanError(filepath, err)
 
-		return false
+		return broken
 	}
 
-	fullpath := fileutils.ResolvePath(filepath, decodedPath)
+	fullpath, ok := resolveTarget(c.root, filepath, decodedPath)
+	link.Target = fullpath
 
-	// If target does not exist, report it
-	if !fileutils.FileExists(fullpath) {
-		report.BrokenLink(filepath, link, "target not found", link.LineContent)
+	if !ok {
+		return c.broken(filepath, link, rule.RootNotFound)
+	}
 
-		return false
+	// If target does not exist, report it. The file itself exists, even when it is an unsaved editor buffer.
+	if decodedPath != "" && !fileutils.FileExists(fullpath) {
+		link.Fix = suggestTarget(link, fullpath)
+
+		return c.broken(filepath, link, rule.TargetNotFound)
 	}
 
 	// Then check if the target is a directory and has an anchor
 	isDir, err := fileutils.IsDirectory(fullpath)
 	if err == nil && isDir && link.Anchor != "" {
-		report.BrokenLink(filepath, link, "cannot refer to a heading of a directory", link.LineContent)
-
-		return false
+		return c.broken(filepath, link, rule.DirectoryAnchor)
 	}
 
 	// If link has an anchor, validate it
 	if link.Anchor != "" {
-		return isAnchorValid(filepath, fullpath, link, report)
+		return c.isAnchorValid(filepath, fullpath, link)
 	}
 
-	return true
+	report.ValidLink(filepath, link)
+
+	return valid
+}
+
+func resolveTarget(root, filepath, decodedPath string) (string, bool) {
+	switch {
+	case decodedPath == "":
+		// Same-file anchors such as (#usage) refer to the file itself
+		return filepath, true
+	case link.IsRootRelative(decodedPath):
+		if root == "" {
+			return "", false
+		}
+
+		return fileutils.ResolveRootPath(root, decodedPath), true
+	default:
+		return fileutils.ResolvePath(filepath, decodedPath), true
+	}
 }
 
 var lineRegex = regexp.MustCompile(`^L\d+$`)
 
-func isAnchorValid(filepath, targetpath string, link link.Link, report *reporter.Reporter) bool {
+func (c checker) isAnchorValid(filepath, targetpath string, link link.Link) status {
+	report := c.report
+
 	// Get scan results for the target file once
-	targetFile, err := scan.File(targetpath)
+	targetFile, err := scan.File(targetpath, c.slugger, c.cache)
 	if err != nil {
 		report.ScanError(filepath, err)
 
-		return false
+		return broken
 	}
 
 	// Check if it's a line link (e.g. #L5)
@@ -126,27 +416,27 @@ func isAnchorValid(filepath, targetpath string, link link.Link, report *reporter
 
 		lineNum, err := fileutils.ParseLineNumber(lineNumStr)
 		if err != nil {
-			report.BrokenLink(filepath, link, "invalid line number", link.LineContent)
-
-			return false
+			return c.broken(filepath, link, rule.InvalidLineNumber)
 		}
 
 		// Check that the line number exists in the target file
 		if lineNum <= 0 || lineNum > targetFile.LineCount {
-			report.BrokenLink(filepath, link, "line number out of range", link.LineContent)
-
-			return false
+			return c.broken(filepath, link, rule.LineOutOfRange)
 		}
 
-		return true
+		report.ValidLink(filepath, link)
+
+		return valid
 	}
 
 	// It's a regular anchor link
-	if !anchor.Exists(targetFile.Anchors, link.Anchor) {
-		report.BrokenLink(filepath, link, "heading not found", link.LineContent)
+	if !anchor.Exists(c.slugger, targetFile.Anchors, link.Anchor) {
+		link.Fix = suggestAnchor(link, targetFile.Anchors)
 
-		return false
+		return c.broken(filepath, link, rule.HeadingNotFound)
 	}
 
-	return true
+	report.ValidLink(filepath, link)
+
+	return valid
 }
@@ -0,0 +1,92 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+)
+
+// suggestTarget looks for a similarly named file next to a missing target, e.g. README.md for REDME.md
+func suggestTarget(brokenLink link.Link, fullpath string) string {
+	entries, err := os.ReadDir(filepath.Dir(fullpath))
+	if err != nil {
+		return ""
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	name, ok := closest(filepath.Base(fullpath), names)
+	if !ok {
+		return ""
+	}
+
+	// Only the last path segment changes, so ./ prefixes and directories stay as written
+	path := brokenLink.Path[:strings.LastIndex(brokenLink.Path, "/")+1] + link.EncodeName(name)
+	if brokenLink.Anchor != "" {
+		path += "#" + brokenLink.Anchor
+	}
+
+	return path
+}
+
+// suggestAnchor looks for a similar anchor in the target file, e.g. #installation for #instalation
+func suggestAnchor(brokenLink link.Link, anchors []string) string {
+	anchor, ok := closest(brokenLink.Anchor, anchors)
+	if !ok {
+		return ""
+	}
+
+	return brokenLink.Path + "#" + anchor
+}
+
+// closest returns the candidate with the smallest edit distance, if it is close enough to be a typo
+func closest(target string, candidates []string) (string, bool) {
+	best, bestDistance := "", -1
+
+	for _, candidate := range candidates {
+		distance := editDistance(strings.ToLower(target), strings.ToLower(candidate))
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	maxDistance := max(2, len(target)/4)
+	if bestDistance < 0 || bestDistance > maxDistance {
+		return "", false
+	}
+
+	return best, true
+}
+
+// editDistance is the Levenshtein distance between two strings, counted in runes
+func editDistance(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(target)]
+}
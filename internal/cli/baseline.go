@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/anttiharju/relcheck/internal/baseline"
+	"github.com/anttiharju/relcheck/internal/check"
+	"github.com/anttiharju/relcheck/internal/exitcode"
+	"github.com/anttiharju/relcheck/internal/fileutils"
+	"github.com/anttiharju/relcheck/internal/git"
+)
+
+// baselinePath returns the given baseline file, or .relcheck-baseline.json of the repository root
+// so that it is the same file wherever relcheck runs, falling back to the working directory outside of Git
+func baselinePath(ctx context.Context, path string) string {
+	if path != "" {
+		return path
+	}
+
+	root, err := git.TopLevel(ctx)
+	if err != nil {
+		return baseline.FileName
+	}
+
+	return filepath.Join(root, baseline.FileName)
+}
+
+// loadBaseline reads the given baseline file, or the default one if it exists
+func loadBaseline(ctx context.Context, path string) (*baseline.Baseline, error) {
+	if path == "" {
+		path = baselinePath(ctx, "")
+		if !fileutils.FileExists(path) {
+			return baseline.Empty(), nil
+		}
+	}
+
+	known, err := baseline.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load baseline: %w", err)
+	}
+
+	return known, nil
+}
+
+// recordBaseline writes the current broken links to the baseline instead of reporting them
+func recordBaseline(ctx context.Context, opts Options, checkOpts check.Options, files []string) exitcode.Exitcode {
+	recorder := baseline.NewRecorder(baselinePath(ctx, opts.Baseline), os.Stdout)
+	checkOpts.Report = recorder
+
+	if err := recorder.Keep(files); err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	// An interrupted run leaves the baseline as it was
+	if check.RelativeLinksAndAnchors(ctx, checkOpts, files) == exitcode.Interrupt {
+		return exitcode.Interrupt
+	}
+
+	// Broken links are expected, files that cannot be checked are not
+	if recorder.Failed() {
+		return exitcode.BrokenLinks
+	}
+
+	return exitcode.Success
+}
@@ -1,14 +1,23 @@
 package cli
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/anttiharju/relcheck/internal/buildinfo"
 	"github.com/anttiharju/relcheck/internal/check"
+	"github.com/anttiharju/relcheck/internal/config"
 	"github.com/anttiharju/relcheck/internal/exitcode"
 	"github.com/anttiharju/relcheck/internal/git"
+	"github.com/anttiharju/relcheck/internal/markdown/anchor"
+	"github.com/anttiharju/relcheck/internal/markdown/scan"
+	"github.com/anttiharju/relcheck/internal/reporter"
 	"github.com/anttiharju/relcheck/internal/usage"
 )
 
@@ -20,12 +29,27 @@ const (
 	RunOnAllMarkdown
 	RunOnInputFiles
 	InvalidArgs
+	LanguageServer
+	RenameAnchor
+	Move
 )
 
 type Options struct {
-	Verbose    bool
-	ForceColor bool
-	Directory  string
+	Verbose     bool
+	ForceColor  bool
+	Directory   string
+	Root        string
+	AnchorStyle anchor.Style
+	Format      reporter.Format
+	Output      string // File to write the report to instead of stdout
+	Config      string // Configuration file, found from the working directory upwards if empty
+	Baseline    string // Baseline file, .relcheck-baseline.json of the repository root if empty
+	Record      bool   // Write the baseline instead of checking against it
+	Jobs        int    // Files checked in parallel, GOMAXPROCS if zero
+	CacheDir    string // Keeps scan results between runs, overriding cache-dir of the configuration file
+	NoCache     bool
+	Watch       bool // Check again whenever files change, until interrupted
+	DryRun      bool // Print the changes of a refactoring as a diff instead of making them
 }
 
 func Start(ctx context.Context, info buildinfo.BuildInfo, args []string) exitcode.Exitcode {
@@ -36,23 +60,150 @@ func Start(ctx context.Context, info buildinfo.BuildInfo, args []string) exitcod
 		return usage.Print()
 	case ShowVersion:
 		return buildinfo.Print(info)
-	case RunOnAllMarkdown:
-		return check.RelativeLinksAndAnchors(opts.Verbose, opts.ForceColor, git.ListMarkdownFiles(ctx))
 	case InvalidArgs:
 		return exitcode.InvalidArgs
-	case RunOnInputFiles:
+	case LanguageServer:
+		return serveLanguageServer(ctx, opts)
+	case RenameAnchor:
+		return renameAnchor(ctx, opts, inputFiles)
+	case Move:
+		return move(ctx, opts, inputFiles)
+	case RunOnAllMarkdown, RunOnInputFiles:
 		fallthrough
 	default:
-		return check.RelativeLinksAndAnchors(opts.Verbose, opts.ForceColor, inputFiles)
+		return run(ctx, cmd, opts, inputFiles)
 	}
 }
 
+func run(ctx context.Context, cmd Command, opts Options, inputFiles []string) exitcode.Exitcode {
+	if opts.Watch {
+		return watch(ctx, cmd, opts, inputFiles)
+	}
+
+	return checkOnce(ctx, cmd, opts, inputFiles)
+}
+
+func checkOnce(ctx context.Context, cmd Command, opts Options, inputFiles []string) exitcode.Exitcode {
+	cfg, err := loadConfig(opts.Config)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	files := checkedFiles(ctx, cmd, cfg, inputFiles)
+
+	output := io.Writer(os.Stdout)
+
+	if opts.Output != "" {
+		file, err := os.Create(opts.Output)
+		if err != nil {
+			fmt.Printf("Error: Unable to create output file: %v\n", err)
+
+			return exitcode.InvalidArgs
+		}
+		defer file.Close()
+
+		output = file
+	}
+
+	checkOpts, err := checkOptions(ctx, opts, cfg, output)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	if opts.Record {
+		return recordBaseline(ctx, opts, checkOpts, files)
+	}
+
+	checkOpts.Baseline, err = loadBaseline(ctx, opts.Baseline)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	return check.RelativeLinksAndAnchors(ctx, checkOpts, files)
+}
+
+// checkedFiles returns the files given, or all Markdown files for "relcheck all", as the configuration filters them
+func checkedFiles(ctx context.Context, cmd Command, cfg config.Config, inputFiles []string) []string {
+	files := inputFiles
+	if cmd == RunOnAllMarkdown {
+		files = git.ListMarkdownFiles(ctx, cfg.Extensions)
+	}
+
+	return filterFiles(cfg, files)
+}
+
+// checkOptions combines flags with the configuration file, flags take precedence
+func checkOptions(ctx context.Context, opts Options, cfg config.Config, output io.Writer) (check.Options, error) {
+	// Outside of a Git repository root-relative links are reported as broken
+	repository, _ := git.TopLevel(ctx)
+	root := cmp.Or(opts.Root, repository)
+
+	style := cmp.Or(opts.AnchorStyle, anchor.Style(cfg.AnchorStyle), anchor.GitHub)
+
+	slugger, err := anchor.New(style)
+	if err != nil {
+		return check.Options{}, fmt.Errorf("invalid anchor style: %w", err)
+	}
+
+	format := cmp.Or(opts.Format, reporter.Format(cfg.Format), reporter.DefaultFormat())
+
+	report, err := reporter.New(format, output, repository, opts.Verbose, opts.ForceColor)
+	if err != nil {
+		return check.Options{}, fmt.Errorf("invalid format: %w", err)
+	}
+
+	return check.Options{
+		Root:     root,
+		Slugger:  slugger,
+		Report:   report,
+		Ignore:   cfg.IsIgnored,
+		Severity: cfg.Severity,
+		Baseline: nil,
+		Jobs:     opts.Jobs,
+		Cache:    diskCache(opts, cfg, style),
+	}, nil
+}
+
+// diskCache returns nil unless a cache directory is given, or when caching is not possible, which only costs time.
+// Caching is opt-in, as the directory is pruned of entries unused for a week and CI runs start from scratch anyway.
+func diskCache(opts Options, cfg config.Config, style anchor.Style) *scan.DiskCache {
+	dir := cmp.Or(opts.CacheDir, cfg.CacheDir)
+	if opts.NoCache || dir == "" {
+		return nil
+	}
+
+	cache, err := scan.NewDiskCache(dir, style)
+	if err != nil {
+		return nil
+	}
+
+	return cache
+}
+
 func ParseArgs(args []string) (Command, Options, []string) {
 	command := RunOnInputFiles // default
 	options := Options{
-		Verbose:    false,
-		ForceColor: false,
-		Directory:  "",
+		Verbose:     false,
+		ForceColor:  false,
+		Directory:   "",
+		Root:        "",
+		AnchorStyle: "", // Flags, then the configuration file, then github
+		Format:      "", // Flags, then the configuration file, then reporter.DefaultFormat
+		Output:      "",
+		Config:      "",
+		Baseline:    "",
+		Record:      false,
+		Jobs:        0,
+		CacheDir:    "",
+		NoCache:     false,
+		Watch:       false,
+		DryRun:      false,
 	}
 	inputFiles := []string{}
 
@@ -66,6 +217,51 @@ func ParseArgs(args []string) (Command, Options, []string) {
 		}
 	}
 
+	// The output, configuration, baseline and cache paths are relative to where relcheck was started, not to -C
+	if options.Output != "" {
+		output, err := filepath.Abs(options.Output)
+		if err != nil {
+			fmt.Println("Error: Unable to resolve output file.")
+
+			command = InvalidArgs
+		}
+
+		options.Output = output
+	}
+
+	if options.Config != "" {
+		configPath, err := filepath.Abs(options.Config)
+		if err != nil {
+			fmt.Println("Error: Unable to resolve configuration file.")
+
+			command = InvalidArgs
+		}
+
+		options.Config = configPath
+	}
+
+	if options.Baseline != "" {
+		baselinePath, err := filepath.Abs(options.Baseline)
+		if err != nil {
+			fmt.Println("Error: Unable to resolve baseline file.")
+
+			command = InvalidArgs
+		}
+
+		options.Baseline = baselinePath
+	}
+
+	if options.CacheDir != "" {
+		cacheDir, err := filepath.Abs(options.CacheDir)
+		if err != nil {
+			fmt.Println("Error: Unable to resolve cache directory.")
+
+			command = InvalidArgs
+		}
+
+		options.CacheDir = cacheDir
+	}
+
 	if options.Directory != "" {
 		if err := os.Chdir(options.Directory); err != nil {
 			fmt.Println("Error: Unable to change directory.")
@@ -74,8 +270,42 @@ func ParseArgs(args []string) (Command, Options, []string) {
 		}
 	}
 
+	if options.Root != "" {
+		root, err := filepath.Abs(options.Root)
+		if err != nil {
+			fmt.Println("Error: Unable to resolve root directory.")
+
+			command = InvalidArgs
+		}
+
+		options.Root = root
+	}
+
+	if options.Jobs < 0 {
+		fmt.Println("Error: --jobs must be a positive number.")
+
+		command = InvalidArgs
+	}
+
+	if command == RenameAnchor && len(inputFiles) != 3 {
+		fmt.Println("Error: rename-anchor expects <file> <anchor> <new heading>.")
+
+		command = InvalidArgs
+	}
+
+	if command == Move && len(inputFiles) != 2 {
+		fmt.Println("Error: mv expects <source> <destination>.")
+
+		command = InvalidArgs
+	}
+
 	if command == RunOnInputFiles && len(inputFiles) == 0 {
 		command = Usage // fallback
+
+		// A baseline and watching cover all files unless told otherwise
+		if options.Record || options.Watch {
+			command = RunOnAllMarkdown
+		}
 	}
 
 	return command, options, inputFiles
@@ -89,6 +319,13 @@ func handleOption(
 	index *int,
 	args []string,
 ) bool {
+	// Arguments of refactorings may look like commands, such as a heading called "all"
+	if (*command == RenameAnchor || *command == Move) && !strings.HasPrefix(arg, "-") {
+		*inputFiles = append(*inputFiles, arg)
+
+		return true
+	}
+
 	switch arg {
 	case "--verbose":
 		options.Verbose = true
@@ -101,11 +338,123 @@ func handleOption(
 		} else {
 			*command = Usage
 		}
+	case "--root":
+		if *index < len(args) {
+			options.Root = args[*index]
+			*index++
+		} else {
+			*command = Usage
+		}
+	case "--anchor-style":
+		if *index < len(args) {
+			options.AnchorStyle = anchor.Style(args[*index])
+			*index++
+		} else {
+			*command = Usage
+		}
+	case "--format":
+		if *index < len(args) {
+			options.Format = reporter.Format(args[*index])
+			*index++
+		} else {
+			*command = Usage
+		}
+	case "--output":
+		if *index < len(args) {
+			options.Output = args[*index]
+			*index++
+		} else {
+			*command = Usage
+		}
+	case "--config":
+		if *index < len(args) {
+			options.Config = args[*index]
+			*index++
+		} else {
+			*command = Usage
+		}
+	case "--baseline":
+		if *index < len(args) {
+			options.Baseline = args[*index]
+			*index++
+		} else {
+			*command = Usage
+		}
+	case "--jobs":
+		if *index < len(args) {
+			options.Jobs = parseJobs(args[*index])
+			*index++
+		} else {
+			*command = Usage
+		}
+	case "--cache-dir":
+		if *index < len(args) {
+			options.CacheDir = args[*index]
+			*index++
+		} else {
+			*command = Usage
+		}
+	case "--no-cache":
+		options.NoCache = true
+	case "--dry-run":
+		options.DryRun = true
+	case "baseline":
+		options.Record = true
+	case "watch":
+		options.Watch = true
+	case "lsp":
+		*command = LanguageServer
+	case "rename-anchor":
+		*command = RenameAnchor
+	case "mv":
+		*command = Move
 	case "version", "-v", "--version":
 		*command = ShowVersion
 	case "all":
 		*command = RunOnAllMarkdown
 	default:
+		if style, ok := strings.CutPrefix(arg, "--anchor-style="); ok {
+			options.AnchorStyle = anchor.Style(style)
+
+			break
+		}
+
+		if format, ok := strings.CutPrefix(arg, "--format="); ok {
+			options.Format = reporter.Format(format)
+
+			break
+		}
+
+		if output, ok := strings.CutPrefix(arg, "--output="); ok {
+			options.Output = output
+
+			break
+		}
+
+		if configPath, ok := strings.CutPrefix(arg, "--config="); ok {
+			options.Config = configPath
+
+			break
+		}
+
+		if baselinePath, ok := strings.CutPrefix(arg, "--baseline="); ok {
+			options.Baseline = baselinePath
+
+			break
+		}
+
+		if cacheDir, ok := strings.CutPrefix(arg, "--cache-dir="); ok {
+			options.CacheDir = cacheDir
+
+			break
+		}
+
+		if jobs, ok := strings.CutPrefix(arg, "--jobs="); ok {
+			options.Jobs = parseJobs(jobs)
+
+			break
+		}
+
 		*command = RunOnInputFiles
 
 		*inputFiles = append(*inputFiles, arg)
@@ -113,3 +462,13 @@ func handleOption(
 
 	return true
 }
+
+// parseJobs returns -1 for anything but a positive number, which ParseArgs rejects
+func parseJobs(value string) int {
+	jobs, err := strconv.Atoi(value)
+	if err != nil || jobs < 1 {
+		return -1
+	}
+
+	return jobs
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/anttiharju/relcheck/internal/config"
+)
+
+// loadConfig reads the given configuration file, or the nearest .relcheck.yml if none is given
+func loadConfig(path string) (config.Config, error) {
+	if path == "" {
+		found, err := config.Find(".")
+		if err != nil {
+			return config.Config{}, fmt.Errorf("failed to look for %s: %w", config.FileName, err)
+		}
+
+		if found == "" {
+			return config.Empty(), nil
+		}
+
+		path = found
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return config.Config{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// filterFiles applies the include and exclude globs of the configuration
+func filterFiles(cfg config.Config, files []string) []string {
+	if len(cfg.Include) == 0 && len(cfg.Exclude) == 0 {
+		return files
+	}
+
+	return slices.DeleteFunc(slices.Clone(files), func(file string) bool {
+		return !cfg.Includes(file)
+	})
+}
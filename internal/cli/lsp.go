@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/anttiharju/relcheck/internal/exitcode"
+	"github.com/anttiharju/relcheck/internal/git"
+	"github.com/anttiharju/relcheck/internal/lsp"
+)
+
+// serveLanguageServer talks to an editor over stdin and stdout until it exits
+func serveLanguageServer(ctx context.Context, opts Options) exitcode.Exitcode {
+	server := lsp.NewServer(os.Stdin, os.Stdout, func(ctx context.Context) (lsp.Workspace, error) {
+		// Loaded once the server is within the workspace, to find its configuration and repository root
+		cfg, err := loadConfig(opts.Config)
+		if err != nil {
+			return lsp.Workspace{}, err
+		}
+
+		checkOpts, err := checkOptions(ctx, opts, cfg, io.Discard)
+		if err != nil {
+			return lsp.Workspace{}, err
+		}
+
+		return lsp.Workspace{
+			Options: checkOpts,
+			Files: func(ctx context.Context) []string {
+				return git.ListMarkdownFiles(ctx, cfg.Extensions)
+			},
+		}, nil
+	})
+
+	shutdown, err := server.Run(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	// The protocol asks for exit code 1 when the client exits without shutting the server down first
+	if !shutdown {
+		return exitcode.Interrupt
+	}
+
+	return exitcode.Success
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/anttiharju/relcheck/internal/exitcode"
+	"github.com/anttiharju/relcheck/internal/git"
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/refactor"
+)
+
+// refactorOptions lets refactorings see every Markdown file that may link to the changed ones
+func refactorOptions(ctx context.Context, opts Options) (refactor.Options, error) {
+	cfg, err := loadConfig(opts.Config)
+	if err != nil {
+		return refactor.Options{}, err
+	}
+
+	checkOpts, err := checkOptions(ctx, opts, cfg, io.Discard)
+	if err != nil {
+		return refactor.Options{}, err
+	}
+
+	return refactor.Options{
+		Root:    checkOpts.Root,
+		Slugger: checkOpts.Slugger,
+		Files:   git.ListMarkdownFiles(ctx, cfg.Extensions),
+		Read:    os.ReadFile,
+		Skip:    warnSkipped,
+	}, nil
+}
+
+// warnSkipped tells of a link that has to be updated by hand, on standard error to keep the output of --dry-run a diff
+func warnSkipped(file string, l link.Link) {
+	fmt.Fprintf(os.Stderr, "Warning: %s:%d:%d: unable to update %s, its source text cannot be rewritten\n",
+		file, l.Line, l.Column, l.URL)
+}
+
+// renameAnchor renames the heading of args[0] that links refer to as args[1] to args[2]
+func renameAnchor(ctx context.Context, opts Options, args []string) exitcode.Exitcode {
+	refactorOpts, err := refactorOptions(ctx, opts)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	file, oldAnchor, newName := args[0], args[1], args[2]
+
+	changes, heading, err := refactor.RenameAnchor(refactorOpts, file, oldAnchor, newName)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	if opts.DryRun {
+		return printDiff(changes, nil)
+	}
+
+	edited, err := refactor.Prepare(changes)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	if err := edited.Write(nil); err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	fmt.Printf("Renamed %s:%d to %q\n", file, heading.Line, newName)
+	printUpdates(changes, nil, heading)
+
+	return exitcode.Success
+}
+
+// move moves args[0] to args[1] with Git and rewrites the links to and within the moved files
+func move(ctx context.Context, opts Options, args []string) exitcode.Exitcode {
+	refactorOpts, err := refactorOptions(ctx, opts)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	source := filepath.Clean(args[0])
+
+	destination, err := refactor.Destination(source, filepath.Clean(args[1]))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	changes, moves, err := refactor.Move(refactorOpts, source, destination)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	if opts.DryRun {
+		return printDiff(changes, moves)
+	}
+
+	// Every edit is made in memory before Git moves, which is before anything is written,
+	// so that nothing changes if either fails
+	edited, err := refactor.Prepare(changes)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	if err := git.Move(ctx, source, destination); err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	if err := edited.Write(moves); err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	fmt.Printf("Moved %s to %s\n", source, destination)
+	printUpdates(changes, moves, refactor.Edit{})
+
+	return exitcode.Success
+}
+
+func printDiff(changes refactor.Changes, moves refactor.Moves) exitcode.Exitcode {
+	if err := refactor.Diff(os.Stdout, changes, moves, os.ReadFile); err != nil {
+		fmt.Printf("Error: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	return exitcode.Success
+}
+
+// printUpdates lists the rewritten links where they are after the refactoring, leaving out the given edit
+func printUpdates(changes refactor.Changes, moves refactor.Moves, except refactor.Edit) {
+	for _, path := range slices.Sorted(maps.Keys(changes)) {
+		for _, edit := range changes[path] {
+			if edit != except {
+				fmt.Printf("Updated %s:%d:%d\n", cmp.Or(moves[path], path), edit.Line, edit.Column)
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anttiharju/relcheck/internal/check"
+	"github.com/anttiharju/relcheck/internal/color"
+	"github.com/anttiharju/relcheck/internal/exitcode"
+	"github.com/anttiharju/relcheck/internal/git"
+	"github.com/anttiharju/relcheck/internal/markdown/anchor"
+	"github.com/anttiharju/relcheck/internal/markdown/scan"
+	watchpkg "github.com/anttiharju/relcheck/internal/watch"
+)
+
+// Saving several files, or one file in several steps, gives a single check
+const debounce = 200 * time.Millisecond
+
+// watch checks again whenever files change, rescanning only the changed ones, until interrupted
+func watch(ctx context.Context, cmd Command, opts Options, inputFiles []string) exitcode.Exitcode {
+	// Links may point anywhere within the repository
+	dir := opts.Root
+	if dir == "" {
+		dir, _ = git.TopLevel(ctx)
+	}
+
+	changes, err := watchpkg.Changes(ctx, cmp.Or(dir, "."))
+	if err != nil {
+		fmt.Printf("Error: Unable to watch for changes: %v\n", err)
+
+		return exitcode.InvalidArgs
+	}
+
+	// A report redirected to a file, as in relcheck watch > report.txt, is written on every check
+	var stdout os.FileInfo
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode().IsRegular() {
+		stdout = info
+	}
+
+	for {
+		// A fresh report each time rather than one appended to the last
+		if opts.Output == "" && color.IsTerminal(os.Stdout) {
+			fmt.Print("\033[H\033[2J")
+		}
+
+		checkOnce(ctx, cmd, opts, inputFiles)
+
+		if ctx.Err() != nil {
+			return exitcode.Success
+		}
+
+		fmt.Fprintln(os.Stderr, "Watching for changes, press Ctrl+C to stop.")
+
+		changed, ok := collectChanges(ctx, changes, watchedFiles(ctx, cmd, opts, inputFiles, stdout))
+		if !ok {
+			return exitcode.Success
+		}
+
+		scan.Forget(changed)
+	}
+}
+
+// collectChanges waits for a change that can affect the report and the ones following it within the debounce time
+func collectChanges(ctx context.Context, changes <-chan string, files watched) ([]string, bool) {
+	changed := make(map[string]bool)
+
+	var settled <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case path, ok := <-changes:
+			if !ok {
+				return nil, false
+			}
+
+			// Forget expects absolute paths, which the watcher gives only for an absolute directory
+			if absolutePath, err := filepath.Abs(path); err == nil && files.relevant(absolutePath) {
+				changed[absolutePath] = true
+				settled = time.After(debounce)
+			}
+		case <-settled:
+			return slices.Sorted(maps.Keys(changed)), true
+		}
+	}
+}
+
+// watched tells which changes can affect the report. Files that relcheck writes itself, such as the report,
+// the cache and the baseline, are left out so that writing them does not start another check.
+type watched struct {
+	all        bool            // When the files are not known, such as with an invalid configuration
+	extensions []string        // Of Markdown files, which may be checked or be linked to once created
+	targets    map[string]bool // The checked files, their link targets and the configuration file
+	ignored    []string        // Files and directories written by relcheck
+	stdout     os.FileInfo     // The file the report is redirected to, if any
+}
+
+// watchedFiles finds what the last check depended on. The files were scanned by the check, so this takes no time.
+func watchedFiles(ctx context.Context, cmd Command, opts Options, inputFiles []string, stdout os.FileInfo) watched {
+	files := watched{
+		all:        true,
+		extensions: []string{".md"}, // As for git.ListMarkdownFiles
+		targets:    make(map[string]bool),
+		ignored:    []string{},
+		stdout:     stdout,
+	}
+
+	for _, path := range []string{opts.Output, opts.CacheDir, baselinePath(ctx, opts.Baseline)} {
+		if absolutePath, err := filepath.Abs(path); path != "" && err == nil {
+			files.ignored = append(files.ignored, absolutePath)
+		}
+	}
+
+	cfg, err := loadConfig(opts.Config)
+	if err != nil {
+		return files
+	}
+
+	slugger, err := anchor.New(cmp.Or(opts.AnchorStyle, anchor.Style(cfg.AnchorStyle), anchor.GitHub))
+	if err != nil {
+		return files
+	}
+
+	if cfg.CacheDir != "" {
+		files.ignored = append(files.ignored, cfg.CacheDir)
+	}
+
+	if len(cfg.Extensions) > 0 {
+		files.extensions = cfg.Extensions
+	}
+
+	root := opts.Root
+	if root == "" {
+		root, _ = git.TopLevel(ctx)
+	}
+
+	files.all = false
+	files.add(cfg.Path)
+
+	for _, file := range checkedFiles(ctx, cmd, cfg, inputFiles) {
+		files.add(file)
+
+		result, err := scan.File(file, slugger, nil)
+		if err != nil {
+			continue
+		}
+
+		for _, l := range result.Links {
+			path, _, _ := strings.Cut(l.Path, "?")
+			if target, ok := check.Target(root, file, path); ok {
+				files.add(target)
+			}
+		}
+	}
+
+	return files
+}
+
+func (w watched) add(path string) {
+	if absolutePath, err := filepath.Abs(path); path != "" && err == nil {
+		w.targets[absolutePath] = true
+	}
+}
+
+// relevant tells whether a change to an absolute path can affect the report
+func (w watched) relevant(path string) bool {
+	if slices.ContainsFunc(w.ignored, func(ignored string) bool { return within(path, ignored) }) {
+		return false
+	}
+
+	if info, err := os.Stat(path); err == nil && w.stdout != nil && os.SameFile(info, w.stdout) {
+		return false
+	}
+
+	if w.all || w.targets[path] || slices.Contains(w.extensions, filepath.Ext(path)) {
+		return true
+	}
+
+	// A directory that was moved or deleted may hold checked files or link targets
+	for target := range w.targets {
+		if within(target, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// within tells whether path is dir or below it
+func within(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
@@ -1,6 +1,7 @@
 package color
 
 import (
+	"io"
 	"os"
 )
 
@@ -23,8 +24,8 @@ type Palette struct {
 	Reset  string
 }
 
-func GetPalette(forceColor bool) Palette {
-	useColors := isTerminal() || forceColor
+func GetPalette(output io.Writer, forceColor bool) Palette {
+	useColors := IsTerminal(output) || forceColor
 
 	if useColors {
 		return Palette{
@@ -40,8 +41,14 @@ func GetPalette(forceColor bool) Palette {
 	return Palette{"", "", "", "", "", ""} // in case program is being piped into a file or another command
 }
 
-func isTerminal() bool {
-	fileInfo, err := os.Stdout.Stat()
+// IsTerminal tells whether output is shown to a person rather than written to a file or a pipe
+func IsTerminal(output io.Writer) bool {
+	file, ok := output.(*os.File)
+	if !ok {
+		return false
+	}
+
+	fileInfo, err := file.Stat()
 	if err != nil {
 		return false
 	}
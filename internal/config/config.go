@@ -0,0 +1,268 @@
+// Package config reads the project configuration from .relcheck.yml.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/anttiharju/relcheck/internal/fileutils"
+	"github.com/anttiharju/relcheck/internal/rule"
+)
+
+const FileName = ".relcheck.yml"
+
+var (
+	errNotMapping     = errors.New("expected a mapping")
+	errNotList        = errors.New("expected a list or a single value")
+	errNotString      = errors.New("expected a single value")
+	errUnknownSetting = errors.New("unknown setting")
+)
+
+// Config mirrors .relcheck.yml, empty fields are left to command line flags and defaults.
+// Every glob is relative to the directory of the configuration file, or to the working directory without one.
+type Config struct {
+	Path        string           // File the configuration was read from, empty if there is none
+	Include     []fileutils.Glob // Files to check, all files if empty
+	Exclude     []fileutils.Glob // Files to skip
+	Extensions  []string         // Extensions of Markdown files for "relcheck all", such as .md
+	AnchorStyle string
+	Format      string
+	Ignore      []fileutils.Glob         // Link targets that are not checked, wherever the links are
+	Severity    map[string]rule.Severity // By rule ID
+	CacheDir    string                   // Keeps scan results between runs, none are kept if empty
+}
+
+func Empty() Config {
+	return Config{
+		Path:        "",
+		Include:     []fileutils.Glob{},
+		Exclude:     []fileutils.Glob{},
+		Extensions:  []string{},
+		AnchorStyle: "",
+		Format:      "",
+		Ignore:      []fileutils.Glob{},
+		Severity:    map[string]rule.Severity{},
+		CacheDir:    "",
+	}
+}
+
+// Find walks up from dir to the first directory with a .relcheck.yml, returning an empty path if there is none
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	for {
+		candidate := filepath.Join(dir, FileName)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
+func Load(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	cfg, err := parse(string(content))
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	cfg.Path = path
+
+	// Like globs, the cache directory is relative to the configuration file
+	if cfg.CacheDir != "" && !filepath.IsAbs(cfg.CacheDir) {
+		cfg.CacheDir = filepath.Join(filepath.Dir(path), cfg.CacheDir)
+	}
+
+	return cfg, nil
+}
+
+func parse(source string) (Config, error) {
+	document, err := parseYAML(source)
+	if err != nil {
+		return Config{}, err
+	}
+
+	mapping, ok := document.(map[string]any)
+	if !ok {
+		return Config{}, errNotMapping
+	}
+
+	cfg := Empty()
+
+	for key, value := range mapping {
+		if err := cfg.set(key, value); err != nil {
+			return Config{}, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+func (cfg *Config) set(key string, value any) error {
+	var err error
+
+	switch key {
+	case "include":
+		cfg.Include, err = globs(value)
+	case "exclude":
+		cfg.Exclude, err = globs(value)
+	case "extensions":
+		cfg.Extensions, err = stringList(value)
+		for i, extension := range cfg.Extensions {
+			// Both md and .md are accepted
+			cfg.Extensions[i] = "." + strings.TrimPrefix(extension, ".")
+		}
+	case "anchor-style":
+		cfg.AnchorStyle, err = singleString(value)
+	case "format":
+		cfg.Format, err = singleString(value)
+	case "ignore":
+		cfg.Ignore, err = globs(value)
+	case "severity":
+		cfg.Severity, err = severities(value)
+	case "cache-dir":
+		cfg.CacheDir, err = singleString(value)
+	default:
+		return errUnknownSetting
+	}
+
+	return err
+}
+
+// Includes tells whether a file, relative to the working directory, is included and not excluded
+func (cfg Config) Includes(file string) bool {
+	relativePath := cfg.relative(file)
+
+	included := len(cfg.Include) == 0 || matchAny(cfg.Include, relativePath)
+
+	return included && !matchAny(cfg.Exclude, relativePath)
+}
+
+// IsIgnored tells whether a link target, relative to the working directory, is not checked
+func (cfg Config) IsIgnored(target string) bool {
+	return matchAny(cfg.Ignore, cfg.relative(target))
+}
+
+// relative makes a path relative to the directory of the configuration file, which globs are written against
+func (cfg Config) relative(path string) string {
+	absolutePath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	relativePath, err := filepath.Rel(filepath.Dir(cfg.Path), absolutePath)
+	if err != nil {
+		return path
+	}
+
+	return relativePath
+}
+
+func matchAny(globs []fileutils.Glob, path string) bool {
+	return slices.ContainsFunc(globs, func(glob fileutils.Glob) bool {
+		return glob.Match(path)
+	})
+}
+
+// globs compiles the patterns once, as every file and link is matched against them
+func globs(value any) ([]fileutils.Glob, error) {
+	patterns, err := stringList(value)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled := make([]fileutils.Glob, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		glob, err := fileutils.CompileGlob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile: %w", err)
+		}
+
+		compiled = append(compiled, glob)
+	}
+
+	return compiled, nil
+}
+
+func stringList(value any) ([]string, error) {
+	switch typed := value.(type) {
+	case string:
+		if typed == "" {
+			return []string{}, nil
+		}
+
+		return []string{typed}, nil
+	case []any:
+		list := make([]string, 0, len(typed))
+
+		for _, item := range typed {
+			text, ok := item.(string)
+			if !ok {
+				return nil, errNotList
+			}
+
+			list = append(list, text)
+		}
+
+		return list, nil
+	}
+
+	return nil, errNotList
+}
+
+func singleString(value any) (string, error) {
+	text, ok := value.(string)
+	if !ok {
+		return "", errNotString
+	}
+
+	return text, nil
+}
+
+func severities(value any) (map[string]rule.Severity, error) {
+	mapping, ok := value.(map[string]any)
+	if !ok {
+		return nil, errNotMapping
+	}
+
+	result := make(map[string]rule.Severity, len(mapping))
+
+	for key, value := range mapping {
+		known, ok := rule.Lookup(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown rule %q", key)
+		}
+
+		text, err := singleString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+
+		severity, err := rule.ParseSeverity(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+
+		result[known.ID] = severity
+	}
+
+	return result, nil
+}
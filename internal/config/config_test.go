@@ -0,0 +1,133 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/anttiharju/relcheck/internal/config"
+	"github.com/anttiharju/relcheck/internal/rule"
+)
+
+func load(t *testing.T, source string) config.Config {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), config.FileName)
+	if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	return cfg
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	cfg := load(t, `
+extensions: [md, .markdown]
+anchor-style: gitlab # it's the style of our wiki
+severity:
+  heading-not-found: warning
+  RC002: off
+cache-dir: .cache/relcheck
+`)
+
+	if got, want := cfg.Extensions, []string{".md", ".markdown"}; !slices.Equal(got, want) {
+		t.Errorf("Extensions = %q, want %q", got, want)
+	}
+
+	if cfg.AnchorStyle != "gitlab" {
+		t.Errorf("AnchorStyle = %q, want gitlab", cfg.AnchorStyle)
+	}
+
+	if got := cfg.Severity[rule.HeadingNotFound.ID]; got != rule.Warning {
+		t.Errorf("severity of %s = %q, want warning", rule.HeadingNotFound.ID, got)
+	}
+
+	if got := cfg.Severity["RC002"]; got != rule.Off {
+		t.Errorf("severity of RC002 = %q, want off", got)
+	}
+
+	if want := filepath.Join(filepath.Dir(cfg.Path), ".cache", "relcheck"); cfg.CacheDir != want {
+		t.Errorf("CacheDir = %q, want %q", cfg.CacheDir, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"unknown setting":  "colour: always",
+		"unknown rule":     "severity:\n  no-such-rule: off",
+		"invalid severity": "severity:\n  RC001: loud",
+		"list for a value": "format: [json, text]",
+		"not a mapping":    "- json",
+	}
+
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), config.FileName)
+			if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := config.Load(path); err == nil {
+				t.Errorf("Load of %q succeeded, want an error", source)
+			}
+		})
+	}
+}
+
+// Globs are relative to the directory of the configuration file, wherever relcheck runs
+func TestGlobs(t *testing.T) {
+	t.Parallel()
+
+	cfg := load(t, `
+include: ["**/*.md"]
+exclude:
+  - drafts/**
+  - CHANGELOG.md
+ignore: [generated/**, "*.pdf"]
+`)
+	dir := filepath.Dir(cfg.Path)
+
+	includes := map[string]bool{
+		"README.md":                 true,
+		"docs/setup.md":             true,
+		"docs/notes.txt":            false,
+		"drafts/idea.md":            false,
+		"docs/drafts/idea.md":       true,
+		"CHANGELOG.md":              false,
+		"docs/CHANGELOG.md":         false,
+		"docs/../drafts/wip/new.md": false,
+	}
+
+	for file, want := range includes {
+		if got := cfg.Includes(filepath.Join(dir, file)); got != want {
+			t.Errorf("Includes(%s) = %v, want %v", file, got, want)
+		}
+	}
+
+	ignored := map[string]bool{
+		"generated/api.md":        true,
+		"generated/v2/api.md":     true,
+		"docs/generated/api.md":   false,
+		"docs/manual.pdf":         true,
+		"docs/generated.md":       false,
+		"docs/../generated/x.png": true,
+	}
+
+	for target, want := range ignored {
+		if got := cfg.IsIgnored(filepath.Join(dir, target)); got != want {
+			t.Errorf("IsIgnored(%s) = %v, want %v", target, got, want)
+		}
+	}
+}
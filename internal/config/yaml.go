@@ -0,0 +1,333 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The configuration is read with a small YAML subset: block mappings and sequences,
+// flow sequences such as [.md, .markdown], quoted and plain scalars, and comments.
+// Values are map[string]any, []any or string.
+
+var errTabIndent = errors.New("tabs are not allowed for indentation")
+
+type yamlLine struct {
+	number int // 1-based
+	indent int
+	text   string // Without indentation and comments
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	index int
+}
+
+func parseYAML(source string) (any, error) {
+	lines, err := yamlLines(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+
+	parser := &yamlParser{lines: lines, index: 0}
+
+	value, err := parser.parseBlock(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.index < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[parser.index].number)
+	}
+
+	return value, nil
+}
+
+func yamlLines(source string) ([]yamlLine, error) {
+	lines := []yamlLine{}
+
+	for i, raw := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n") {
+		content := strings.TrimLeft(raw, " ")
+		indent := len(raw) - len(content)
+
+		content = strings.TrimRight(stripComment(content), " \t")
+		if content == "" || content == "---" {
+			continue
+		}
+
+		if strings.HasPrefix(content, "\t") {
+			return nil, fmt.Errorf("line %d: %w", i+1, errTabIndent)
+		}
+
+		lines = append(lines, yamlLine{number: i + 1, indent: indent, text: content})
+	}
+
+	return lines, nil
+}
+
+// stripComment removes a # comment that is not within a quoted scalar.
+// Quotes only start a scalar at its beginning, so that plain scalars such as it's may contain them.
+func stripComment(s string) string {
+	var quote byte
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == '"' && c == '\\', quote == '\'' && strings.HasPrefix(s[i:], "''"):
+			i++ // Escaped character such as \" or ''
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && startsScalar(s[:i]):
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+
+	return s
+}
+
+// startsScalar tells whether a scalar may begin after before, such as after "key: ", "- " or "[a, "
+func startsScalar(before string) bool {
+	trimmed := strings.TrimRight(before, " \t")
+	if trimmed == "" {
+		return true
+	}
+
+	switch trimmed[len(trimmed)-1] {
+	case '[', '{', ',':
+		return true
+	case ':', '-':
+		// Only indicators followed by a space, unlike the colon of a URL
+		return len(trimmed) < len(before)
+	}
+
+	return false
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) parseBlock(indent int) (any, error) {
+	if isSequenceItem(p.lines[p.index].text) {
+		return p.parseSequence(indent)
+	}
+
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseSequence(indent int) ([]any, error) {
+	items := []any{}
+
+	for p.index < len(p.lines) {
+		line := p.lines[p.index]
+		if line.indent != indent || !isSequenceItem(line.text) {
+			break
+		}
+
+		content := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if content == "" {
+			p.index++
+
+			item, err := p.parseNested(indent)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, item)
+
+			continue
+		}
+
+		if _, _, isMapping := splitKey(content); isMapping {
+			// An item such as "- key: value" starts a mapping indented past the dash
+			itemIndent := indent + len(line.text) - len(content)
+			p.lines[p.index] = yamlLine{number: line.number, indent: itemIndent, text: content}
+
+			item, err := p.parseMapping(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, item)
+
+			continue
+		}
+
+		item, err := parseScalar(content, line.number)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+		p.index++
+	}
+
+	return items, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (map[string]any, error) {
+	mapping := map[string]any{}
+
+	for p.index < len(p.lines) {
+		line := p.lines[p.index]
+		if line.indent < indent {
+			break
+		}
+
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+
+		key, rest, ok := splitKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", line.number)
+		}
+
+		if _, duplicate := mapping[key]; duplicate {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.number, key)
+		}
+
+		p.index++
+
+		if rest != "" {
+			value, err := parseScalar(rest, line.number)
+			if err != nil {
+				return nil, err
+			}
+
+			mapping[key] = value
+
+			continue
+		}
+
+		value, err := p.parseNested(indent)
+		if err != nil {
+			return nil, err
+		}
+
+		mapping[key] = value
+	}
+
+	return mapping, nil
+}
+
+// parseNested parses the value that follows a "key:" or "-" line, if there is one
+func (p *yamlParser) parseNested(parentIndent int) (any, error) {
+	if p.index >= len(p.lines) {
+		return "", nil
+	}
+
+	next := p.lines[p.index]
+
+	// Sequences may be indented at the same level as their key
+	if next.indent > parentIndent || (next.indent == parentIndent && isSequenceItem(next.text)) {
+		return p.parseBlock(next.indent)
+	}
+
+	return "", nil
+}
+
+// splitKey splits "key: value" into its parts
+func splitKey(text string) (string, string, bool) {
+	if strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'") || strings.HasPrefix(text, "[") {
+		return "", "", false
+	}
+
+	before, after, found := strings.Cut(text, ":")
+	if !found || (after != "" && after[0] != ' ') {
+		return "", "", false
+	}
+
+	return strings.TrimSpace(before), strings.TrimSpace(after), true
+}
+
+func parseScalar(text string, lineNumber int) (any, error) {
+	switch {
+	case strings.HasPrefix(text, "["):
+		return parseFlowSequence(text, lineNumber)
+	case strings.HasPrefix(text, "{"):
+		if text == "{}" {
+			return map[string]any{}, nil
+		}
+
+		return nil, fmt.Errorf("line %d: flow mappings are not supported, use an indented block instead", lineNumber)
+	case strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'"):
+		return unquote(text, lineNumber)
+	}
+
+	return text, nil
+}
+
+func parseFlowSequence(text string, lineNumber int) ([]any, error) {
+	if !strings.HasSuffix(text, "]") {
+		return nil, fmt.Errorf("line %d: unterminated sequence", lineNumber)
+	}
+
+	items := []any{}
+	inner := strings.TrimSpace(text[1 : len(text)-1])
+
+	if inner == "" {
+		return items, nil
+	}
+
+	var quote byte
+
+	start := 0
+
+	for i := 0; i <= len(inner); i++ {
+		if i < len(inner) {
+			switch c := inner[i]; {
+			case quote == '"' && c == '\\' && i+1 < len(inner), quote == '\'' && strings.HasPrefix(inner[i:], "''"):
+				i++
+
+				continue
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+
+				continue
+			case (c == '"' || c == '\'') && strings.TrimSpace(inner[start:i]) == "":
+				quote = c
+
+				continue
+			case c != ',':
+				continue
+			}
+		}
+
+		item, err := parseScalar(strings.TrimSpace(inner[start:i]), lineNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+		start = i + 1
+	}
+
+	return items, nil
+}
+
+func unquote(text string, lineNumber int) (string, error) {
+	quote := text[0]
+	if len(text) < 2 || text[len(text)-1] != quote {
+		return "", fmt.Errorf("line %d: unterminated string", lineNumber)
+	}
+
+	inner := text[1 : len(text)-1]
+
+	if quote == '\'' {
+		return strings.ReplaceAll(inner, "''", "'"), nil
+	}
+
+	replacer := strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\n`, "\n", `\t`, "\t")
+
+	return replacer.Replace(inner), nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		source string
+		want   any
+	}{
+		{name: "empty", source: "", want: map[string]any{}},
+		{name: "document start and comments", source: "---\n# only a comment\n", want: map[string]any{}},
+		{name: "plain scalar", source: "format: json", want: map[string]any{"format": "json"}},
+		{name: "comment after a value", source: "format: json # for CI", want: map[string]any{"format": "json"}},
+		{name: "apostrophe in a plain scalar", source: "name: it's # note", want: map[string]any{"name": "it's"}},
+		{name: "hash without a space", source: "name: a#b", want: map[string]any{"name": "a#b"}},
+		{name: "single-quoted", source: "name: 'it''s # not a comment'", want: map[string]any{"name": "it's # not a comment"}},
+		{name: "double-quoted", source: `name: "say \"hi\" # here" # there`, want: map[string]any{"name": `say "hi" # here`}},
+		{name: "colon within a value", source: "url: https://example.com", want: map[string]any{"url": "https://example.com"}},
+		{name: "empty mapping", source: "severity: {}", want: map[string]any{"severity": map[string]any{}}},
+		{
+			name:   "flow sequence",
+			source: "extensions: [.md, '.markdown', \"a, b\", it's]",
+			want:   map[string]any{"extensions": []any{".md", ".markdown", "a, b", "it's"}},
+		},
+		{
+			name:   "escaped quotes in a flow sequence",
+			source: `ignore: ['it''s, here', "a\", b"]`,
+			want:   map[string]any{"ignore": []any{"it's, here", `a", b`}},
+		},
+		{name: "empty flow sequence", source: "ignore: []", want: map[string]any{"ignore": []any{}}},
+		{
+			name:   "block sequence",
+			source: "exclude:\n  - drafts/**   # work in progress\n  - \"CHANGELOG.md\"",
+			want:   map[string]any{"exclude": []any{"drafts/**", "CHANGELOG.md"}},
+		},
+		{
+			name:   "sequence at the indentation of its key",
+			source: "include:\n- docs/**\n- README.md\nformat: text",
+			want:   map[string]any{"include": []any{"docs/**", "README.md"}, "format": "text"},
+		},
+		{
+			name:   "nested mapping",
+			source: "severity:\n  heading-not-found: warning\n  RC002: off",
+			want:   map[string]any{"severity": map[string]any{"heading-not-found": "warning", "RC002": "off"}},
+		},
+		{
+			name:   "mappings within a sequence",
+			source: "items:\n  - name: a\n    value: 1\n  - name: b",
+			want:   map[string]any{"items": []any{map[string]any{"name": "a", "value": "1"}, map[string]any{"name": "b"}}},
+		},
+		{name: "key without a value", source: "ignore:\nformat: json", want: map[string]any{"ignore": "", "format": "json"}},
+		{
+			name:   "windows line endings",
+			source: "format: json\r\nanchor-style: gitlab\r\n",
+			want:   map[string]any{"format": "json", "anchor-style": "gitlab"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseYAML(test.source)
+			if err != nil {
+				t.Fatalf("parseYAML(%q) failed: %v", test.source, err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseYAML(%q) = %#v, want %#v", test.source, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"tab indentation":       "severity:\n\theading-not-found: off",
+		"unexpected indent":     "format: json\n  anchor-style: github",
+		"duplicate key":         "format: json\nformat: text",
+		"missing colon":         "format json",
+		"unterminated string":   "format: 'json",
+		"unterminated sequence": "ignore: [a, b",
+		"flow mapping":          "severity: {RC001: off}",
+	}
+
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, err := parseYAML(source); err == nil {
+				t.Errorf("parseYAML(%q) = %#v, want an error", source, got)
+			}
+		})
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
 func FileExists(path string) bool {
@@ -58,3 +61,73 @@ func CountLines(path string) (int, error) {
 
 	return lineCount, nil
 }
+
+func ResolveRootPath(root, rootRelativePath string) string {
+	path := filepath.Join(root, rootRelativePath)
+
+	// Relative to the working directory like other resolved paths, so reports do not depend on the checkout location
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	relativePath, err := filepath.Rel(workingDirectory, path)
+	if err != nil {
+		return path
+	}
+
+	return relativePath
+}
+
+// Glob is a compiled pattern for slash-separated paths where ** spans directories, like docs/**/*.md.
+// Globs without a slash match the base name in any directory, like CHANGELOG.md.
+type Glob struct {
+	pattern  *regexp.Regexp
+	baseName bool
+}
+
+func CompileGlob(pattern string) (Glob, error) {
+	pattern = strings.TrimPrefix(pattern, "./")
+
+	var builder strings.Builder
+
+	builder.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			builder.WriteString("(?:.*/)?")
+
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			builder.WriteString(".*")
+
+			i++
+		case c == '*':
+			builder.WriteString("[^/]*")
+		case c == '?':
+			builder.WriteString("[^/]")
+		default:
+			builder.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	builder.WriteString("$")
+
+	compiled, err := regexp.Compile(builder.String())
+	if err != nil {
+		return Glob{}, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+
+	return Glob{pattern: compiled, baseName: !strings.Contains(pattern, "/")}, nil
+}
+
+func (g Glob) Match(name string) bool {
+	name = filepath.ToSlash(filepath.Clean(name))
+
+	if g.baseName {
+		name = path.Base(name)
+	}
+
+	return g.pattern.MatchString(name)
+}
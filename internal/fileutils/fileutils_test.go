@@ -0,0 +1,49 @@
+package fileutils_test
+
+import (
+	"testing"
+
+	"github.com/anttiharju/relcheck/internal/fileutils"
+)
+
+func TestGlob(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "*.md", name: "README.md", want: true},
+		{pattern: "*.md", name: "docs/setup.md", want: true}, // No slash, so the base name is matched
+		{pattern: "*.md", name: "README.markdown", want: false},
+		{pattern: "docs/*.md", name: "docs/setup.md", want: true},
+		{pattern: "docs/*.md", name: "docs/guides/setup.md", want: false},
+		{pattern: "docs/**/*.md", name: "docs/setup.md", want: true},
+		{pattern: "docs/**/*.md", name: "docs/guides/advanced/setup.md", want: true},
+		{pattern: "docs/**", name: "docs/guides/logo.png", want: true},
+		{pattern: "docs/**", name: "other/docs/logo.png", want: false},
+		{pattern: "./docs/*.md", name: "docs/setup.md", want: true},
+		{pattern: "docs/*.md", name: "./docs/setup.md", want: true},
+		{pattern: "docs/?.md", name: "docs/a.md", want: true},
+		{pattern: "docs/?.md", name: "docs/ab.md", want: false},
+		{pattern: "v1.[0-9]/*.md", name: "v1.[0-9]/notes.md", want: true}, // Only * and ? are special
+		{pattern: "v1.[0-9]/*.md", name: "v1.5/notes.md", want: false},
+		{pattern: "a+b/*.md", name: "a+b/c.md", want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.name, func(t *testing.T) {
+			t.Parallel()
+
+			glob, err := fileutils.CompileGlob(test.pattern)
+			if err != nil {
+				t.Fatalf("CompileGlob(%q) failed: %v", test.pattern, err)
+			}
+
+			if got := glob.Match(test.name); got != test.want {
+				t.Errorf("%q matching %q = %v, want %v", test.pattern, test.name, got, test.want)
+			}
+		})
+	}
+}
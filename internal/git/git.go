@@ -3,11 +3,22 @@ package git
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"os/exec"
 )
 
-func ListMarkdownFiles(ctx context.Context) []string {
-	out, err := exec.CommandContext(ctx, "git", "ls-files", "-z", "*.md").Output()
+// ListMarkdownFiles lists tracked files with the given extensions, .md if there are none
+func ListMarkdownFiles(ctx context.Context, extensions []string) []string {
+	if len(extensions) == 0 {
+		extensions = []string{".md"}
+	}
+
+	args := []string{"ls-files", "-z"}
+	for _, extension := range extensions {
+		args = append(args, "*"+extension)
+	}
+
+	out, err := exec.CommandContext(ctx, "git", args...).Output()
 	if err != nil {
 		return nil
 	}
@@ -28,3 +39,22 @@ func ListMarkdownFiles(ctx context.Context) []string {
 
 	return files
 }
+
+func TopLevel(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find repository root: %w", err)
+	}
+
+	return string(bytes.TrimSpace(out)), nil
+}
+
+// Move runs git mv, which also moves the files on disk
+func Move(ctx context.Context, source, destination string) error {
+	out, err := exec.CommandContext(ctx, "git", "mv", "--", source, destination).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git mv failed: %s", bytes.TrimSpace(out))
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package interrupt
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
@@ -9,12 +10,27 @@ import (
 	"github.com/anttiharju/relcheck/internal/exitcode"
 )
 
-func Listen(exitcode exitcode.Exitcode, signals ...os.Signal) {
-	interruptCh := make(chan os.Signal, 1)
-	signal.Notify(interruptCh, signals...)
-	<-interruptCh
+// Context is cancelled on the first of the signals, so that long-running commands can return cleanly.
+// A second signal is not caught anymore and ends the program right away.
+func Context(parent context.Context, signals ...os.Signal) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(parent, signals...)
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return ctx, stop
+}
+
+// Exitcode reports an interruption, returning exitCode if there was none
+func Exitcode(ctx context.Context, exitCode exitcode.Exitcode) exitcode.Exitcode {
+	if ctx.Err() == nil {
+		return exitCode
+	}
 
 	programName := filepath.Base(os.Args[0])
 	fmt.Printf("\n%s: interrupted\n", programName) // leading \n to have ^C appear on its own line
-	os.Exit(int(exitcode))
+
+	return exitcode.Interrupt
 }
@@ -0,0 +1,165 @@
+package lsp
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anttiharju/relcheck/internal/check"
+	"github.com/anttiharju/relcheck/internal/fileutils"
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/markdown/scan"
+)
+
+// completion offers paths relative to the document after ]( and the anchors of the target after #
+func (s *Server) completion(params textDocumentPositionParams) any {
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+
+	lines := strings.Split(doc.text, "\n")
+	if params.Position.Line >= len(lines) {
+		return nil
+	}
+
+	line := strings.TrimSuffix(lines[params.Position.Line], "\r")
+	cursor := byteColumn(line, params.Position.Character) - 1
+
+	destination, ok := destinationBefore(line[:cursor])
+	if !ok {
+		return nil
+	}
+
+	// The text being completed ends at the cursor and starts after the last # or /
+	path, anchorPrefix, hasAnchor := strings.Cut(destination, "#")
+	replaced := destination[strings.LastIndex(destination, "/")+1:]
+
+	if hasAnchor {
+		replaced = anchorPrefix
+	}
+
+	replace := textRange{
+		Start: position{Line: params.Position.Line, Character: utf16Column(line, cursor-len(replaced)+1)},
+		End:   params.Position,
+	}
+
+	if hasAnchor {
+		return completionList{IsIncomplete: false, Items: s.anchorItems(doc, path, replace)}
+	}
+
+	return completionList{IsIncomplete: false, Items: s.pathItems(doc, destination, replace)}
+}
+
+// destinationBefore returns the link destination being typed, as in [text](./do
+func destinationBefore(text string) (string, bool) {
+	start := strings.LastIndex(text, "](")
+	if start < 0 {
+		return "", false
+	}
+
+	// Nothing typed yet is relative too
+	destination := text[start+2:]
+	if strings.ContainsAny(destination, " \t)") || (destination != "" && !link.IsRelative(destination)) {
+		return "", false
+	}
+
+	return destination, true
+}
+
+func (s *Server) anchorItems(doc *document, path string, replace textRange) []completionItem {
+	result := doc.result
+
+	// Anchors of the same document are offered for (#
+	if path != "" {
+		target, ok := check.Target(s.opts.Root, doc.path, path)
+		if !ok {
+			return []completionItem{}
+		}
+
+		targetFile, err := scan.File(target, s.opts.Slugger, s.opts.Cache)
+		if err != nil {
+			return []completionItem{}
+		}
+
+		result = targetFile
+	}
+
+	items := make([]completionItem, 0, len(result.Anchors))
+
+	for i, anchor := range result.Anchors {
+		items = append(items, completionItem{
+			Label:      anchor,
+			Kind:       completionKindReference,
+			Detail:     anchorDetail(result, i),
+			SortText:   sortText(i),
+			FilterText: anchor,
+			TextEdit:   textEdit{Range: replace, NewText: anchor},
+		})
+	}
+
+	return items
+}
+
+func (s *Server) pathItems(doc *document, destination string, replace textRange) []completionItem {
+	dirPart := destination[:strings.LastIndex(destination, "/")+1]
+
+	// The directory part is resolved like a link to a directory would be
+	dir, ok := check.Target(s.opts.Root, doc.path, cmp.Or(dirPart, "."))
+	if !ok {
+		return []completionItem{}
+	}
+
+	if isDir, err := fileutils.IsDirectory(dir); err != nil || !isDir {
+		return []completionItem{}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return []completionItem{}
+	}
+
+	items := make([]completionItem, 0, len(entries))
+
+	for i, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+
+		name := link.EncodeName(entry.Name())
+		item := completionItem{
+			Label:      entry.Name(),
+			Kind:       completionKindFile,
+			Detail:     filepath.Join(dir, entry.Name()),
+			SortText:   sortText(i),
+			FilterText: name,
+			TextEdit:   textEdit{Range: replace, NewText: name},
+		}
+
+		if entry.IsDir() {
+			item.Label += "/"
+			item.Kind = completionKindFolder
+			item.FilterText += "/"
+			item.TextEdit.NewText += "/"
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func anchorDetail(result scan.Result, index int) string {
+	if index >= len(result.AnchorLines) {
+		return ""
+	}
+
+	return fmt.Sprintf("line %d", result.AnchorLines[index])
+}
+
+// sortText keeps the order of the document or directory rather than an alphabetical one
+func sortText(index int) string {
+	return fmt.Sprintf("%05d", index)
+}
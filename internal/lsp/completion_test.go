@@ -0,0 +1,83 @@
+package lsp_test
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type completionItem struct {
+	Label    string `json:"label"`
+	TextEdit struct {
+		NewText string `json:"newText"`
+	} `json:"textEdit"`
+}
+
+func TestCompletion(t *testing.T) {
+	t.Parallel()
+
+	dir := workspace(t, map[string]string{
+		"issues caught.markdown": "# Broken links\n\n## Fixed ones\n",
+		"c++ (draft).md":         "",
+		"guides/setup.md":        "",
+	})
+
+	uri := fileURI(dir, "index.md")
+	lines := []string{"[a](", "[b](guides/", "[c](issues%20caught.markdown#", "[d](https://"}
+
+	messages := []map[string]any{
+		{"id": 0, "method": "initialize", "params": map[string]any{}},
+		{"method": "textDocument/didOpen", "params": map[string]any{
+			"textDocument": map[string]any{"uri": uri, "text": strings.Join(lines, "\n")},
+		}},
+	}
+
+	for i, line := range lines {
+		params := map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"position":     map[string]any{"line": i, "character": len(line)},
+		}
+		messages = append(messages, map[string]any{"id": i + 1, "method": "textDocument/completion", "params": params})
+	}
+
+	results := serve(t, messages...).results
+
+	tests := []struct {
+		name string
+		want []string // Inserted texts, by label
+	}{
+		{name: "names are encoded", want: []string{
+			"c++ (draft).md", "c%2B%2B%20%28draft%29.md",
+			"guides/", "guides/",
+			"issues caught.markdown", "issues%20caught.markdown",
+		}},
+		{name: "subdirectory", want: []string{"setup.md", "setup.md"}},
+		{name: "anchors of an encoded path", want: []string{"broken-links", "broken-links", "fixed-ones", "fixed-ones"}},
+		{name: "not relative", want: nil},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var list struct {
+				Items []completionItem `json:"items"`
+			}
+
+			if err := json.Unmarshal(results[i+1], &list); err != nil {
+				t.Fatalf("completion result %s: %v", results[i+1], err)
+			}
+
+			var got []string
+
+			for _, item := range list.Items {
+				got = append(got, item.Label, item.TextEdit.NewText)
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("completion of %q = %q, want %q", lines[i], got, test.want)
+			}
+		})
+	}
+}
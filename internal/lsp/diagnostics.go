@@ -0,0 +1,109 @@
+package lsp
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/anttiharju/relcheck/internal/check"
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/markdown/scan"
+	"github.com/anttiharju/relcheck/internal/rule"
+)
+
+// publishAll checks every open document again, as a change to one can break or fix links of the others
+func (s *Server) publishAll() error {
+	// Unsaved headings count for links from the other documents
+	for _, doc := range s.documents {
+		doc.result, doc.err = scan.Content([]byte(doc.text), s.opts.Slugger)
+		if doc.err != nil {
+			scan.Forget([]string{absolutePath(doc.path)})
+
+			continue
+		}
+
+		scan.Remember(doc.path, doc.result)
+	}
+
+	for _, uri := range slices.Sorted(maps.Keys(s.documents)) {
+		if err := s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: s.diagnose(s.documents[uri]),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) diagnose(doc *document) []diagnostic {
+	collector := &collector{diagnostics: []diagnostic{}}
+
+	if doc.err != nil {
+		collector.ScanError(doc.path, doc.err)
+
+		return collector.diagnostics
+	}
+
+	opts := s.opts
+	opts.Report = collector
+
+	check.Content(opts, doc.path, doc.result)
+
+	return collector.diagnostics
+}
+
+// collector is a reporter that turns the broken links of one document into diagnostics
+type collector struct {
+	diagnostics []diagnostic
+}
+
+func (c *collector) FileNotFound(_ string) {}
+
+func (c *collector) ScanError(_ string, err error) {
+	c.diagnostics = append(c.diagnostics, diagnostic{
+		Range:    textRange{Start: position{Line: 0, Character: 0}, End: position{Line: 0, Character: 0}},
+		Severity: severityError,
+		Code:     "",
+		Source:   "relcheck",
+		Message:  err.Error(),
+	})
+}
+
+func (c *collector) NoLinks(_ string) {}
+
+func (c *collector) ValidLink(_ string, _ link.Link) {}
+
+func (c *collector) BrokenLink(_ string, brokenLink link.Link, errorType string, _ string) {
+	severity := severityError
+	if brokenLink.Severity == rule.Warning {
+		severity = severityWarning
+	}
+
+	code := ""
+	if brokenRule, ok := rule.ForReason(errorType); ok {
+		code = brokenRule.ID
+	}
+
+	message := fmt.Sprintf("broken relative link (%s)", errorType)
+	if brokenLink.Fix != "" {
+		message += fmt.Sprintf(", did you mean %s?", brokenLink.Fix)
+	}
+
+	c.diagnostics = append(c.diagnostics, diagnostic{
+		Range:    linkRange(brokenLink),
+		Severity: severity,
+		Code:     code,
+		Source:   "relcheck",
+		Message:  message,
+	})
+}
+
+func (c *collector) ValidLinks(_ string, _ int, _ bool) {}
+
+func (c *collector) Success() {}
+
+func (c *collector) Flush() error {
+	return nil
+}
@@ -0,0 +1,111 @@
+package lsp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/anttiharju/relcheck/internal/check"
+	"github.com/anttiharju/relcheck/internal/fileutils"
+	"github.com/anttiharju/relcheck/internal/markdown/anchor"
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/markdown/scan"
+)
+
+var lineAnchorPattern = regexp.MustCompile(`^L(\d+)$`)
+
+// target is where a link leads: a file or directory, and a line within the file if the link has an anchor
+type target struct {
+	path  string
+	line  int // 1-based, 0 if the anchor was not found or there is none
+	found bool
+}
+
+func (s *Server) resolve(doc *document, l link.Link) target {
+	linkPath, _, _ := strings.Cut(l.Path, "?")
+
+	// The document itself exists, even when it is not saved
+	path, ok := check.Target(s.opts.Root, doc.path, linkPath)
+	if !ok || (linkPath != "" && !fileutils.FileExists(path)) {
+		return target{path: path, line: 0, found: false}
+	}
+
+	if l.Anchor == "" {
+		return target{path: path, line: 0, found: true}
+	}
+
+	if match := lineAnchorPattern.FindStringSubmatch(l.Anchor); match != nil {
+		line, _ := strconv.Atoi(match[1])
+
+		return target{path: path, line: line, found: true}
+	}
+
+	// Unsaved documents are in the scan cache too
+	targetFile, err := scan.File(path, s.opts.Slugger, s.opts.Cache)
+	if err != nil {
+		return target{path: path, line: 0, found: true}
+	}
+
+	index := anchor.Index(s.opts.Slugger, targetFile.Anchors, l.Anchor)
+	if index < 0 || index >= len(targetFile.AnchorLines) {
+		return target{path: path, line: 0, found: true}
+	}
+
+	return target{path: path, line: targetFile.AnchorLines[index], found: true}
+}
+
+// definition leads from a link to its target file, or to the heading or line the anchor refers to
+func (s *Server) definition(params textDocumentPositionParams) any {
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+
+	l, ok := linkAt(doc.result.Links, params.Position)
+	if !ok {
+		return nil
+	}
+
+	to := s.resolve(doc, l)
+	if isDir, _ := fileutils.IsDirectory(to.path); !to.found || isDir {
+		return nil
+	}
+
+	line := max(to.line-1, 0)
+
+	return location{
+		URI:   pathToURI(to.path),
+		Range: textRange{Start: position{Line: line, Character: 0}, End: position{Line: line, Character: 0}},
+	}
+}
+
+// hover shows the path a link resolves to
+func (s *Server) hover(params textDocumentPositionParams) any {
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+
+	l, ok := linkAt(doc.result.Links, params.Position)
+	if !ok {
+		return nil
+	}
+
+	to := s.resolve(doc, l)
+
+	var value string
+
+	switch {
+	case to.path == "":
+		value = "Root-relative link, but the repository root is unknown"
+	case !to.found:
+		value = fmt.Sprintf("`%s` (not found)", to.path)
+	case to.line > 0:
+		value = fmt.Sprintf("`%s`, line %d", to.path, to.line)
+	default:
+		value = fmt.Sprintf("`%s`", to.path)
+	}
+
+	return hover{Contents: markupContent{Kind: "markdown", Value: value}, Range: linkRange(l)}
+}
@@ -0,0 +1,102 @@
+package lsp
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"unicode/utf16"
+
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+)
+
+func uriToPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+
+	return filepath.FromSlash(parsed.Path)
+}
+
+func pathToURI(path string) string {
+	uri := url.URL{Scheme: "file", Path: filepath.ToSlash(absolutePath(path))}
+
+	return uri.String()
+}
+
+func absolutePath(path string) string {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return absolute
+}
+
+// relativePath is relative to the working directory, as the scan cache and reports expect
+func relativePath(path string) string {
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	relative, err := filepath.Rel(workingDirectory, path)
+	if err != nil {
+		return path
+	}
+
+	return relative
+}
+
+// utf16Column converts a 1-based byte column into the 0-based UTF-16 offset editors count in
+func utf16Column(line string, byteColumn int) int {
+	end := min(max(byteColumn-1, 0), len(line))
+	units := 0
+
+	for _, r := range line[:end] {
+		units += utf16.RuneLen(r)
+	}
+
+	return units
+}
+
+// byteColumn converts a 0-based UTF-16 offset into a 1-based byte column
+func byteColumn(line string, character int) int {
+	units := 0
+
+	for offset, r := range line {
+		if units >= character {
+			return offset + 1
+		}
+
+		units += utf16.RuneLen(r)
+	}
+
+	return len(line) + 1
+}
+
+// linkRange spans the source text of a link, as far as it is on the line
+func linkRange(l link.Link) textRange {
+	end := min(l.EndColumn, len(l.LineContent)+1)
+
+	return textRange{
+		Start: position{Line: l.Line - 1, Character: utf16Column(l.LineContent, l.Column)},
+		End:   position{Line: l.Line - 1, Character: utf16Column(l.LineContent, end)},
+	}
+}
+
+// linkAt finds the link under the cursor
+func linkAt(links []link.Link, pos position) (link.Link, bool) {
+	for _, l := range links {
+		if l.Line-1 != pos.Line {
+			continue
+		}
+
+		column := byteColumn(l.LineContent, pos.Character)
+		if column >= l.Column && column <= l.EndColumn {
+			return l, true
+		}
+	}
+
+	return link.Link{}, false
+}
@@ -0,0 +1,112 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/anttiharju/relcheck/internal/check"
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/refactor"
+)
+
+// rename renames the heading under the cursor, or the one a link refers to, along with every link to it
+func (s *Server) rename(ctx context.Context, params renameParams) (any, *responseError, error) {
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return nil, nil, nil
+	}
+
+	file, oldAnchor, ok := s.anchorAt(doc, params.Position)
+	if !ok {
+		return nil, &responseError{Code: codeRequestFailed, Message: "no heading or link anchor to rename here"}, nil
+	}
+
+	// Open documents may link to the heading without Git knowing of them yet
+	files := s.files(ctx)
+	for _, open := range s.documents {
+		files = append(files, open.path)
+	}
+
+	var skipped []string
+
+	changes, _, err := refactor.RenameAnchor(refactor.Options{
+		Root:    s.opts.Root,
+		Slugger: s.opts.Slugger,
+		Files:   files,
+		Read:    s.read,
+		Skip: func(file string, l link.Link) {
+			skipped = append(skipped, fmt.Sprintf("%s:%d:%d", file, l.Line, l.Column))
+		},
+	}, file, oldAnchor, params.NewName)
+	if err != nil {
+		return nil, &responseError{Code: codeRequestFailed, Message: err.Error()}, nil
+	}
+
+	// The edit cannot say so itself
+	if len(skipped) > 0 {
+		err := s.notify("window/showMessage", showMessageParams{
+			Type:    messageTypeWarning,
+			Message: "Update these links by hand, their source text cannot be rewritten: " + strings.Join(skipped, ", "),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return toWorkspaceEdit(changes), nil, nil
+}
+
+// anchorAt finds the anchor of the link under the cursor, or of the heading on its line
+func (s *Server) anchorAt(doc *document, pos position) (string, string, bool) {
+	if l, ok := linkAt(doc.result.Links, pos); ok {
+		if l.Anchor == "" || lineAnchorPattern.MatchString(l.Anchor) {
+			return "", "", false
+		}
+
+		path, ok := check.Target(s.opts.Root, doc.path, l.Path)
+
+		return path, l.Anchor, ok
+	}
+
+	index := slices.Index(doc.result.AnchorLines, pos.Line+1)
+	if index < 0 || index >= len(doc.result.Anchors) {
+		return "", "", false
+	}
+
+	return doc.path, doc.result.Anchors[index], true
+}
+
+// read prefers the text of open documents to what is saved
+func (s *Server) read(path string) ([]byte, error) {
+	for _, doc := range s.documents {
+		if absolutePath(doc.path) == absolutePath(path) {
+			return []byte(doc.text), nil
+		}
+	}
+
+	return os.ReadFile(path) //nolint:wrapcheck // refactor adds the path
+}
+
+func toWorkspaceEdit(changes refactor.Changes) workspaceEdit {
+	edit := workspaceEdit{Changes: make(map[string][]textEdit, len(changes))}
+
+	for path, edits := range changes {
+		uri := pathToURI(path)
+
+		for _, change := range edits {
+			line := change.Line - 1
+			edit.Changes[uri] = append(edit.Changes[uri], textEdit{
+				Range: textRange{
+					Start: position{Line: line, Character: utf16Column(change.LineContent, change.Column)},
+					End:   position{Line: line, Character: utf16Column(change.LineContent, change.EndColumn)},
+				},
+				NewText: change.NewText,
+			})
+		}
+	}
+
+	return edit
+}
@@ -0,0 +1,311 @@
+// Package lsp serves the checks of relcheck to editors over the Language Server Protocol on stdio.
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/anttiharju/relcheck/internal/check"
+	"github.com/anttiharju/relcheck/internal/markdown/scan"
+)
+
+const codeRequestFailed = -32803
+
+// Configure returns the options for the workspace, once the server has moved into it
+type Configure func(ctx context.Context) (Workspace, error)
+
+type Workspace struct {
+	Options check.Options
+	Files   func(ctx context.Context) []string // Markdown files that may link to each other, for refactorings
+}
+
+type document struct {
+	text   string
+	path   string      // Relative to the working directory, like the paths of the command line
+	result scan.Result // Of text, which may not be saved
+	err    error       // Of scanning text
+}
+
+type Server struct {
+	reader      *bufio.Reader
+	writer      io.Writer
+	configure   Configure
+	opts        check.Options
+	files       func(ctx context.Context) []string
+	documents   map[string]*document // By URI
+	initialized bool                 // Nothing but initialize is served before it succeeds
+	watchFiles  bool                 // The client can report changes to files that are not open
+	shutdown    bool
+}
+
+func NewServer(input io.Reader, output io.Writer, configure Configure) *Server {
+	return &Server{
+		reader:      bufio.NewReader(input),
+		writer:      output,
+		configure:   configure,
+		opts:        check.Options{}, // Set on initialize
+		files:       nil,             // Set on initialize
+		documents:   make(map[string]*document),
+		initialized: false,
+		watchFiles:  false,
+		shutdown:    false,
+	}
+}
+
+// Run serves the client until it asks the server to exit, returning whether it was shut down properly first
+func (s *Server) Run(ctx context.Context) (bool, error) {
+	messages := make(chan message)
+	readErr := make(chan error, 1)
+
+	go func() {
+		for {
+			msg, err := readMessage(s.reader)
+			if err != nil {
+				readErr <- err
+
+				return
+			}
+
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case err := <-readErr:
+			// A client that goes away without saying so did not shut the server down
+			if errors.Is(err, io.EOF) {
+				return false, nil
+			}
+
+			return false, err
+		case msg := <-messages:
+			if msg.Method == "exit" {
+				return s.shutdown, nil
+			}
+
+			if err := s.handle(ctx, msg); err != nil {
+				return false, err
+			}
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, msg message) error {
+	// Responses to the requests of the server, such as client/registerCapability, need no handling
+	if msg.Method == "" {
+		return nil
+	}
+
+	result, rpcErr, err := s.dispatch(ctx, msg)
+	if err != nil {
+		return err
+	}
+
+	// Notifications have no ID and get no response
+	if len(msg.ID) == 0 {
+		return nil
+	}
+
+	if rpcErr != nil {
+		return writeMessage(s.writer, errorResponse{JSONRPC: "2.0", ID: msg.ID, Error: *rpcErr})
+	}
+
+	return writeMessage(s.writer, response{JSONRPC: "2.0", ID: msg.ID, Result: result})
+}
+
+//nolint:cyclop // one case per method
+func (s *Server) dispatch(ctx context.Context, msg message) (any, *responseError, error) {
+	// Requests fail and notifications are dropped until initialize has configured the server
+	if !s.initialized && msg.Method != "initialize" {
+		return nil, &responseError{Code: codeServerNotInitialized, Message: "server not initialized"}, nil
+	}
+
+	switch msg.Method {
+	case "initialize":
+		var params initializeParams
+		if rpcErr := decode(msg.Params, &params); rpcErr != nil {
+			return nil, rpcErr, nil
+		}
+
+		result, rpcErr := s.initialize(ctx, params)
+
+		return result, rpcErr, nil
+	case "initialized":
+		return nil, nil, s.watch()
+	case "shutdown":
+		s.shutdown = true
+
+		return nil, nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if rpcErr := decode(msg.Params, &params); rpcErr != nil {
+			return nil, rpcErr, nil
+		}
+
+		return nil, nil, s.open(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if rpcErr := decode(msg.Params, &params); rpcErr != nil || len(params.ContentChanges) == 0 {
+			return nil, rpcErr, nil
+		}
+
+		// With full sync the last change holds the whole text
+		return nil, nil, s.open(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didSave":
+		// Saving may come with other files changing, such as after a search and replace, which are scanned again
+		scan.Forget([]string{absolutePath(".")})
+
+		return nil, nil, s.publishAll()
+	case "workspace/didChangeWatchedFiles":
+		var params didChangeWatchedFilesParams
+		if rpcErr := decode(msg.Params, &params); rpcErr != nil {
+			return nil, rpcErr, nil
+		}
+
+		changed := make([]string, 0, len(params.Changes))
+		for _, change := range params.Changes {
+			changed = append(changed, absolutePath(uriToPath(change.URI)))
+		}
+
+		scan.Forget(changed)
+
+		return nil, nil, s.publishAll()
+	case "textDocument/didClose":
+		var params didCloseParams
+		if rpcErr := decode(msg.Params, &params); rpcErr != nil {
+			return nil, rpcErr, nil
+		}
+
+		return nil, nil, s.close(params.TextDocument.URI)
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if rpcErr := decode(msg.Params, &params); rpcErr != nil {
+			return nil, rpcErr, nil
+		}
+
+		return s.definition(params), nil, nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if rpcErr := decode(msg.Params, &params); rpcErr != nil {
+			return nil, rpcErr, nil
+		}
+
+		return s.hover(params), nil, nil
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if rpcErr := decode(msg.Params, &params); rpcErr != nil {
+			return nil, rpcErr, nil
+		}
+
+		return s.completion(params), nil, nil
+	case "textDocument/rename":
+		var params renameParams
+		if rpcErr := decode(msg.Params, &params); rpcErr != nil {
+			return nil, rpcErr, nil
+		}
+
+		return s.rename(ctx, params)
+	}
+
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}, nil
+}
+
+func decode(params json.RawMessage, v any) *responseError {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+
+	return nil
+}
+
+func (s *Server) initialize(ctx context.Context, params initializeParams) (any, *responseError) {
+	root := params.RootURI
+	if len(params.WorkspaceFolders) > 0 {
+		root = params.WorkspaceFolders[0].URI
+	}
+
+	// Paths are relative to the workspace, as when running relcheck from its root
+	if root != "" {
+		if err := os.Chdir(uriToPath(root)); err != nil {
+			return nil, &responseError{Code: codeRequestFailed, Message: fmt.Sprintf("unable to open workspace: %v", err)}
+		}
+	}
+
+	workspace, err := s.configure(ctx)
+	if err != nil {
+		return nil, &responseError{Code: codeRequestFailed, Message: err.Error()}
+	}
+
+	s.opts = workspace.Options
+	s.files = workspace.Files
+	s.initialized = true
+	s.watchFiles = params.Capabilities.Workspace.DidChangeWatchedFiles.DynamicRegistration
+
+	return initializeResult{
+		Capabilities: serverCapabilities{
+			TextDocumentSync:   textDocumentSyncOptions{OpenClose: true, Change: syncFull, Save: true},
+			DefinitionProvider: true,
+			HoverProvider:      true,
+			CompletionProvider: completionOptions{TriggerCharacters: []string{"(", "/", "#"}},
+			RenameProvider:     true,
+		},
+		ServerInfo: serverInfo{Name: "relcheck"},
+	}, nil
+}
+
+// watch asks the client to report changes to files that are not open, which the scan cache would miss
+func (s *Server) watch() error {
+	if !s.watchFiles {
+		return nil
+	}
+
+	return writeMessage(s.writer, request{
+		JSONRPC: "2.0",
+		ID:      "watch",
+		Method:  "client/registerCapability",
+		Params: registrationParams{Registrations: []registration{{
+			ID:              "watch",
+			Method:          "workspace/didChangeWatchedFiles",
+			RegisterOptions: didChangeWatchedFilesRegistrationOptions{Watchers: []fileSystemWatcher{{GlobPattern: watchAll}}},
+		}}},
+	})
+}
+
+func (s *Server) open(uri, text string) error {
+	s.documents[uri] = &document{text: text, path: relativePath(uriToPath(uri)), result: scan.Result{}, err: nil}
+
+	return s.publishAll()
+}
+
+func (s *Server) close(uri string) error {
+	if doc, ok := s.documents[uri]; ok {
+		// Other documents see what is saved again
+		scan.Forget([]string{absolutePath(doc.path)})
+		delete(s.documents, uri)
+	}
+
+	if err := s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: []diagnostic{},
+	}); err != nil {
+		return err
+	}
+
+	return s.publishAll()
+}
+
+func (s *Server) notify(method string, params any) error {
+	return writeMessage(s.writer, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
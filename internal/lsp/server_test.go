@@ -0,0 +1,431 @@
+package lsp_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/anttiharju/relcheck/internal/check"
+	"github.com/anttiharju/relcheck/internal/lsp"
+	"github.com/anttiharju/relcheck/internal/markdown/anchor"
+)
+
+const codeServerNotInitialized = -32002
+
+// session is what the server wrote back to the client
+type session struct {
+	results     map[int]json.RawMessage // By request ID
+	errors      map[int]int             // Error codes, by request ID
+	diagnostics map[string][]string     // Codes of the last diagnostics published, by URI
+}
+
+// serve runs the server over the given messages, collecting its responses and diagnostics
+func serve(t *testing.T, messages ...map[string]any) session {
+	t.Helper()
+
+	slugger, err := anchor.New(anchor.GitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var input bytes.Buffer
+
+	for _, msg := range append(messages, map[string]any{"method": "exit"}) {
+		msg["jsonrpc"] = "2.0"
+
+		content, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintf(&input, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	}
+
+	var output bytes.Buffer
+
+	// Without a root, the server stays in the working directory, so tests can run in parallel
+	server := lsp.NewServer(&input, &output, func(context.Context) (lsp.Workspace, error) {
+		return lsp.Workspace{
+			Options: check.Options{Slugger: slugger},
+			Files:   func(context.Context) []string { return nil },
+		}, nil
+	})
+
+	if _, err := server.Run(t.Context()); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	got := session{
+		results:     make(map[int]json.RawMessage),
+		errors:      make(map[int]int),
+		diagnostics: make(map[string][]string),
+	}
+	reader := bufio.NewReader(&output)
+
+	for {
+		headers, err := textproto.NewReader(reader).ReadMIMEHeader()
+		if errors.Is(err, io.EOF) {
+			return got
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		length, _ := strconv.Atoi(headers.Get("Content-Length"))
+		content := make([]byte, length)
+
+		if _, err := io.ReadFull(reader, content); err != nil {
+			t.Fatal(err)
+		}
+
+		got.add(t, content)
+	}
+}
+
+func (s session) add(t *testing.T, content []byte) {
+	t.Helper()
+
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+
+	if err := json.Unmarshal(content, &msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg.Method == "textDocument/publishDiagnostics" {
+		var params struct {
+			URI         string `json:"uri"`
+			Diagnostics []struct {
+				Code string `json:"code"`
+			} `json:"diagnostics"`
+		}
+
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			t.Fatal(err)
+		}
+
+		codes := []string{}
+		for _, diagnostic := range params.Diagnostics {
+			codes = append(codes, diagnostic.Code)
+		}
+
+		s.diagnostics[params.URI] = codes
+
+		return
+	}
+
+	// Requests of the server, such as client/registerCapability, have string IDs
+	id, err := strconv.Atoi(string(msg.ID))
+	if err != nil {
+		return
+	}
+
+	if msg.Error != nil {
+		s.errors[id] = msg.Error.Code
+	} else {
+		s.results[id] = msg.Result
+	}
+}
+
+// workspace writes the files into a temporary directory, returning the directory
+func workspace(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func fileURI(dir, name string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(dir, name))}).String()
+}
+
+func initialize(id int) map[string]any {
+	return map[string]any{"id": id, "method": "initialize", "params": map[string]any{}}
+}
+
+func didOpen(uri, text string) map[string]any {
+	return map[string]any{"method": "textDocument/didOpen", "params": map[string]any{
+		"textDocument": map[string]any{"uri": uri, "text": text},
+	}}
+}
+
+// at asks for something at a position of a document, such as a definition
+func at(id int, method, uri string, line, character int) map[string]any {
+	return map[string]any{"id": id, "method": method, "params": map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": line, "character": character},
+	}}
+}
+
+func TestInitialize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		messages      func(uri string) []map[string]any
+		wantError     int // Of request 1
+		wantPublished bool
+	}{
+		{
+			name: "request before initialize",
+			messages: func(uri string) []map[string]any {
+				return []map[string]any{at(1, "textDocument/hover", uri, 0, 1)}
+			},
+			wantError:     codeServerNotInitialized,
+			wantPublished: false,
+		},
+		{
+			name: "notification before initialize",
+			messages: func(uri string) []map[string]any {
+				return []map[string]any{didOpen(uri, "[a](missing.md)\n"), initialize(1)}
+			},
+			wantError:     0,
+			wantPublished: false,
+		},
+		{
+			name: "after initialize",
+			messages: func(uri string) []map[string]any {
+				return []map[string]any{
+					initialize(0), didOpen(uri, "[a](missing.md)\n"), at(1, "textDocument/hover", uri, 0, 1),
+				}
+			},
+			wantError:     0,
+			wantPublished: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			uri := fileURI(workspace(t, nil), "index.md")
+			got := serve(t, test.messages(uri)...)
+
+			if code := got.errors[1]; code != test.wantError {
+				t.Errorf("error code = %d, want %d", code, test.wantError)
+			}
+
+			if _, published := got.diagnostics[uri]; published != test.wantPublished {
+				t.Errorf("diagnostics published = %t, want %t", published, test.wantPublished)
+			}
+		})
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		text  string // Of index.md, which is not saved
+		want  []string
+	}{
+		{name: "target not found", files: nil, text: "[a](missing.md)\n", want: []string{"RC002"}},
+		{
+			name:  "heading of a saved file",
+			files: map[string]string{"guide.md": "# Setup\n"},
+			text:  "[a](guide.md#setup)\n",
+			want:  []string{},
+		},
+		{name: "heading of an unsaved file", files: nil, text: "# Draft\n\n[a](#draft)\n", want: []string{}},
+		{name: "heading not found", files: nil, text: "[a](#draft)\n", want: []string{"RC006"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			uri := fileURI(workspace(t, test.files), "index.md")
+			got := serve(t, initialize(0), didOpen(uri, test.text))
+
+			if codes, ok := got.diagnostics[uri]; !ok || !slices.Equal(codes, test.want) {
+				t.Errorf("diagnostics = %q (published: %t), want %q", codes, ok, test.want)
+			}
+		})
+	}
+}
+
+func TestDidChangeWatchedFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		notify bool
+		want   []string
+	}{
+		{name: "scanned again", notify: true, want: []string{}},
+		{name: "cached without a change", notify: false, want: []string{"RC006"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := workspace(t, map[string]string{"guide.md": "# Old\n"})
+			uri := fileURI(dir, "index.md")
+
+			// The scan cache outlives the server, so the second one sees the heading only if told about the change
+			if codes := serve(t, initialize(0), didOpen(uri, "[a](guide.md#new)\n")).diagnostics[uri]; len(codes) != 1 {
+				t.Fatalf("diagnostics before the change = %q, want one", codes)
+			}
+
+			if err := os.WriteFile(filepath.Join(dir, "guide.md"), []byte("# New\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			messages := []map[string]any{initialize(0)}
+			if test.notify {
+				messages = append(messages, map[string]any{"method": "workspace/didChangeWatchedFiles", "params": map[string]any{
+					"changes": []map[string]any{{"uri": fileURI(dir, "guide.md"), "type": 2}},
+				}})
+			}
+
+			got := serve(t, append(messages, didOpen(uri, "[a](guide.md#new)\n"))...)
+
+			if codes := got.diagnostics[uri]; !slices.Equal(codes, test.want) {
+				t.Errorf("diagnostics after the change = %q, want %q", codes, test.want)
+			}
+		})
+	}
+}
+
+// navigation is an unsaved document with a link on each line, for definition and hover
+var navigation = []string{ //nolint:gochecknoglobals
+	"[a](guide.md#setup)",
+	"[b](guide.md)",
+	"[c](#draft)",
+	"[d](missing.md)",
+	"[e](guides/)",
+	"",
+	"# Draft",
+}
+
+func navigate(t *testing.T, method string) (string, session) {
+	t.Helper()
+
+	dir := workspace(t, map[string]string{"guide.md": "# Guide\n\n## Setup\n", "guides/setup.md": ""})
+	uri := fileURI(dir, "index.md")
+
+	messages := []map[string]any{initialize(0), didOpen(uri, strings.Join(navigation, "\n"))}
+	for i := range navigation {
+		messages = append(messages, at(i+1, method, uri, i, len("[a](")))
+	}
+
+	return dir, serve(t, messages...)
+}
+
+func TestDefinition(t *testing.T) {
+	t.Parallel()
+
+	dir, got := navigate(t, "textDocument/definition")
+
+	tests := []struct {
+		name     string
+		wantFile string // Empty if there is no definition
+		wantLine int
+	}{
+		{name: "heading", wantFile: "guide.md", wantLine: 2},
+		{name: "file", wantFile: "guide.md", wantLine: 0},
+		{name: "heading of the unsaved file", wantFile: "index.md", wantLine: 6},
+		{name: "target not found", wantFile: "", wantLine: 0},
+		{name: "directory", wantFile: "", wantLine: 0},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var location *struct {
+				URI   string `json:"uri"`
+				Range struct {
+					Start struct {
+						Line int `json:"line"`
+					} `json:"start"`
+				} `json:"range"`
+			}
+
+			if err := json.Unmarshal(got.results[i+1], &location); err != nil {
+				t.Fatalf("definition result %s: %v", got.results[i+1], err)
+			}
+
+			switch {
+			case test.wantFile == "" && location != nil:
+				t.Errorf("definition of %q = %s, want none", navigation[i], got.results[i+1])
+			case test.wantFile == "":
+			case location == nil:
+				t.Errorf("definition of %q = none, want %s", navigation[i], test.wantFile)
+			case location.URI != fileURI(dir, test.wantFile) || location.Range.Start.Line != test.wantLine:
+				t.Errorf("definition of %q = %s line %d, want %s line %d", navigation[i],
+					location.URI, location.Range.Start.Line, test.wantFile, test.wantLine)
+			}
+		})
+	}
+}
+
+func TestHover(t *testing.T) {
+	t.Parallel()
+
+	_, got := navigate(t, "textDocument/hover")
+
+	tests := []struct {
+		name string
+		want string // End of the hover text, as the path is relative to the working directory
+	}{
+		{name: "heading", want: "guide.md`, line 3"},
+		{name: "file", want: "guide.md`"},
+		{name: "heading of the unsaved file", want: "index.md`, line 7"},
+		{name: "target not found", want: "missing.md` (not found)"},
+		{name: "directory", want: "guides`"},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var hover struct {
+				Contents struct {
+					Value string `json:"value"`
+				} `json:"contents"`
+			}
+
+			if err := json.Unmarshal(got.results[i+1], &hover); err != nil {
+				t.Fatalf("hover result %s: %v", got.results[i+1], err)
+			}
+
+			if !strings.HasSuffix(hover.Contents.Value, test.want) {
+				t.Errorf("hover of %q = %q, want it to end with %q", navigation[i], hover.Contents.Value, test.want)
+			}
+		})
+	}
+}
@@ -1,39 +1,95 @@
 package anchor
 
 import (
-	"regexp"
+	"fmt"
+	"net/url"
 	"slices"
 	"strings"
 )
 
-var validCharactersPattern = regexp.MustCompile(`[^a-z0-9 _-]`)
+// Slugger generates heading anchors the way a particular Markdown renderer does
+type Slugger interface {
+	// Slug turns heading text into an anchor, without handling duplicates
+	Slug(heading string) string
+	// Unique disambiguates an anchor from the ones generated earlier in the same document
+	Unique(anchor string, seen map[string]int) string
+	// Normalise prepares an anchor written in a link for comparison, accepting its percent-encoded form as well
+	Normalise(anchor string) string
+}
 
-var multipleHyphensPattern = regexp.MustCompile(`-+`)
+type Style string
 
-func normalise(anchor string) string {
-	normalised := strings.ToLower(anchor)
+const (
+	GitHub      Style = "github"
+	GitLab      Style = "gitlab"
+	MkDocs      Style = "mkdocs"
+	Hugo        Style = "hugo"
+	Docusaurus  Style = "docusaurus"
+	AzureDevOps Style = "azure-devops"
+)
 
-	// Remove anything that's not alphanumeric, space, underscore, or hyphen
-	normalised = validCharactersPattern.ReplaceAllString(normalised, "")
+func Styles() []Style {
+	return []Style{GitHub, GitLab, MkDocs, Hugo, Docusaurus, AzureDevOps}
+}
 
-	// Convert spaces to hyphens
-	normalised = strings.ReplaceAll(normalised, " ", "-")
+//nolint:ireturn // the style decides the implementation
+func New(style Style) (Slugger, error) {
+	switch style {
+	case GitHub, Docusaurus: // Docusaurus uses github-slugger as well
+		return github{}, nil
+	case GitLab:
+		return gitlab{}, nil
+	case MkDocs:
+		return mkdocs{}, nil
+	case Hugo:
+		return hugo{}, nil
+	case AzureDevOps:
+		return azureDevOps{}, nil
+	}
 
-	// Replace multiple hyphens with single hyphen
-	normalised = multipleHyphensPattern.ReplaceAllString(normalised, "-")
+	names := make([]string, 0, len(Styles()))
+	for _, known := range Styles() {
+		names = append(names, string(known))
+	}
 
-	// Trim trailing hyphens
-	normalised = strings.TrimRight(normalised, "-")
+	return nil, fmt.Errorf("unknown anchor style %q, expected one of: %s", style, strings.Join(names, ", "))
+}
 
-	return normalised
+func Exists(slugger Slugger, target []string, source string) bool {
+	return Index(slugger, target, source) >= 0
 }
 
-func GenerateAnchor(heading string) string {
-	return normalise(heading)
+// Index returns the position of the anchor a link refers to within target, or -1 if there is none
+func Index(slugger Slugger, target []string, source string) int {
+	normalizedSource := slugger.Normalise(source)
+
+	return slices.Index(target, normalizedSource)
+}
+
+// hyphenSuffix appends -1, -2, ... to duplicates like github-slugger, skipping anchors that are already taken
+func hyphenSuffix(anchor string, seen map[string]int) string {
+	unique := anchor
+
+	for {
+		if _, taken := seen[unique]; !taken {
+			break
+		}
+
+		seen[anchor]++
+		unique = fmt.Sprintf("%s-%d", anchor, seen[anchor])
+	}
+
+	seen[unique] = 0
+
+	return unique
 }
 
-func Exists(target []string, source string) bool {
-	normalizedSource := normalise(source)
+// percentDecode decodes anchors such as #k%C3%A4ytt%C3%B6, leaving malformed ones as they are
+func percentDecode(anchor string) string {
+	decoded, err := url.PathUnescape(anchor)
+	if err != nil {
+		return anchor
+	}
 
-	return slices.Contains(target, normalizedSource)
+	return decoded
 }
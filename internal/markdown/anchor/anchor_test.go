@@ -0,0 +1,112 @@
+package anchor_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/anttiharju/relcheck/internal/markdown/anchor"
+)
+
+type styleCase struct {
+	headings []string // In document order
+	anchors  []string // Generated for the headings, duplicates included
+	links    map[string]string
+}
+
+//nolint:funlen // one table per style
+func TestStyles(t *testing.T) {
+	t.Parallel()
+
+	tests := map[anchor.Style]styleCase{
+		anchor.GitHub: {
+			headings: []string{"Getting started", "Käyttöönotto", "Before -- after &", "API v2.0 🚀", "Foo", "Foo", "Foo-1"},
+			anchors:  []string{"getting-started", "käyttöönotto", "before----after-", "api-v20-", "foo", "foo-1", "foo-1-1"},
+			links: map[string]string{
+				"Getting-Started":             "getting-started",
+				"k%C3%A4ytt%C3%B6%C3%B6notto": "käyttöönotto",
+				"before----after-":            "before----after-",
+				"malformed%zz":                "malformedzz",
+				"snake_case":                  "snake_case",
+			},
+		},
+		anchor.GitLab: {
+			headings: []string{"Getting started", "Käyttöönotto", "Before -- after &", "API v2.0", "Foo", "Foo"},
+			anchors:  []string{"getting-started", "käyttöönotto", "before-after-", "api-v20", "foo", "foo-1"},
+			links: map[string]string{
+				"Getting-Started":             "getting-started",
+				"k%C3%A4ytt%C3%B6%C3%B6notto": "käyttöönotto",
+				"before--after-":              "before-after-",
+			},
+		},
+		anchor.MkDocs: {
+			headings: []string{"Getting started", "Käyttöönotto", "Ĳssel œuvre", "Ḑay — night", "Foo", "Foo", "Foo"},
+			anchors:  []string{"getting-started", "kayttoonotto", "ijssel-uvre", "day-night", "foo", "foo_1", "foo_2"},
+			links: map[string]string{
+				"Getting-Started":             "getting-started",
+				"käyttöönotto":                "kayttoonotto",
+				"k%C3%A4ytt%C3%B6%C3%B6notto": "kayttoonotto",
+				"foo_1":                       "foo_1",
+			},
+		},
+		anchor.Hugo: {
+			headings: []string{" Getting started ", "Käyttöönotto", "Before -- after &", "snake_case!", "Foo", "Foo"},
+			anchors:  []string{"getting-started", "käyttöönotto", "before----after-", "snake_case", "foo", "foo-1"},
+			links: map[string]string{
+				"Getting-Started":             "getting-started",
+				"k%C3%A4ytt%C3%B6%C3%B6notto": "käyttöönotto",
+			},
+		},
+		anchor.Docusaurus: {
+			headings: []string{"Getting started", "Foo", "Foo"},
+			anchors:  []string{"getting-started", "foo", "foo-1"},
+			links: map[string]string{
+				"Getting-Started": "getting-started",
+			},
+		},
+		anchor.AzureDevOps: {
+			headings: []string{"Getting started", "Q&A: why?", "Foo", "Foo"},
+			anchors:  []string{"getting-started", "q&a:-why?", "foo", "foo-1"},
+			links: map[string]string{
+				"Getting-Started":  "getting-started",
+				"q%26a%3A-why%3F":  "q&a:-why?",
+				"k%C3%A4ytt%C3%B6": "käyttö",
+			},
+		},
+	}
+
+	for style, test := range tests {
+		t.Run(string(style), func(t *testing.T) {
+			t.Parallel()
+
+			slugger, err := anchor.New(style)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			seen := make(map[string]int)
+			anchors := make([]string, 0, len(test.headings))
+
+			for _, heading := range test.headings {
+				anchors = append(anchors, slugger.Unique(slugger.Slug(heading), seen))
+			}
+
+			if !slices.Equal(anchors, test.anchors) {
+				t.Errorf("anchors of %q = %q, want %q", test.headings, anchors, test.anchors)
+			}
+
+			for link, want := range test.links {
+				if got := slugger.Normalise(link); got != want {
+					t.Errorf("Normalise(%q) = %q, want %q", link, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewUnknownStyle(t *testing.T) {
+	t.Parallel()
+
+	if _, err := anchor.New("confluence"); err == nil {
+		t.Error("New(confluence) succeeded, want an error")
+	}
+}
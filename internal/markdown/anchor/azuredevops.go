@@ -0,0 +1,22 @@
+package anchor
+
+import (
+	"strings"
+)
+
+// azureDevOps follows Azure DevOps wikis, which keep punctuation and percent-encode it in links
+type azureDevOps struct{}
+
+func (azureDevOps) Slug(heading string) string {
+	normalised := strings.ToLower(strings.TrimSpace(heading))
+
+	return strings.ReplaceAll(normalised, " ", "-")
+}
+
+func (azureDevOps) Unique(anchor string, seen map[string]int) string {
+	return hyphenSuffix(anchor, seen)
+}
+
+func (a azureDevOps) Normalise(anchor string) string {
+	return a.Slug(percentDecode(anchor))
+}
@@ -0,0 +1,31 @@
+package anchor
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Anything but letters, marks, decimal and letter numbers, connector punctuation, hyphens and spaces.
+// Emoji and other symbols are removed as well, like github-slugger does.
+var githubPunctuationPattern = regexp.MustCompile(`[^\p{L}\p{M}\p{Nd}\p{Nl}\p{Pc}\- ]`)
+
+// github follows the anchors of GitHub and other github-slugger users such as Docusaurus
+type github struct{}
+
+func (github) Slug(heading string) string {
+	normalised := strings.ToLower(heading)
+	normalised = githubPunctuationPattern.ReplaceAllString(normalised, "")
+
+	// Every space becomes a hyphen, repeated and trailing hyphens are kept
+	return strings.ReplaceAll(normalised, " ", "-")
+}
+
+func (github) Unique(anchor string, seen map[string]int) string {
+	return hyphenSuffix(anchor, seen)
+}
+
+// Normalise accepts both the literal and the percent-encoded form of an anchor,
+// e.g. #käyttöönotto and #k%C3%A4ytt%C3%B6%C3%B6notto
+func (g github) Normalise(anchor string) string {
+	return g.Slug(percentDecode(anchor))
+}
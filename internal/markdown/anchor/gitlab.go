@@ -0,0 +1,30 @@
+package anchor
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Anything but letters, marks, digits, connector punctuation, hyphens and spaces
+var gitlabPunctuationPattern = regexp.MustCompile(`[^\p{L}\p{M}\p{Nd}\p{Pc}\- ]`)
+
+var multipleHyphensPattern = regexp.MustCompile(`-+`)
+
+type gitlab struct{}
+
+func (gitlab) Slug(heading string) string {
+	normalised := strings.ToLower(heading)
+	normalised = gitlabPunctuationPattern.ReplaceAllString(normalised, "")
+	normalised = strings.ReplaceAll(normalised, " ", "-")
+
+	// Unlike GitHub, repeated hyphens are squeezed into one
+	return multipleHyphensPattern.ReplaceAllString(normalised, "-")
+}
+
+func (gitlab) Unique(anchor string, seen map[string]int) string {
+	return hyphenSuffix(anchor, seen)
+}
+
+func (g gitlab) Normalise(anchor string) string {
+	return g.Slug(percentDecode(anchor))
+}
@@ -0,0 +1,32 @@
+package anchor
+
+import (
+	"strings"
+	"unicode"
+)
+
+// hugo follows the default "github" auto heading IDs of Hugo's Goldmark renderer
+type hugo struct{}
+
+func (hugo) Slug(heading string) string {
+	var builder strings.Builder
+
+	for _, r := range strings.TrimSpace(heading) {
+		switch {
+		case r == '-' || r == ' ':
+			builder.WriteRune('-')
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(unicode.ToLower(r))
+		}
+	}
+
+	return builder.String()
+}
+
+func (hugo) Unique(anchor string, seen map[string]int) string {
+	return hyphenSuffix(anchor, seen)
+}
+
+func (h hugo) Normalise(anchor string) string {
+	return h.Slug(percentDecode(anchor))
+}
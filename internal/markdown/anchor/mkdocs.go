@@ -0,0 +1,97 @@
+package anchor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ASCII equivalents of Latin letters after NFKD decomposition, '.' where there is none
+const (
+	latinFoldsStart = 0xC0
+	latinFolds      = "AAAAAA.CEEEEIIII.NOOOOO..UUUUY..aaaaaa.ceeeeiiii.nooooo..uuuuy.yAaAaAaCcCcCcCcDd..EeEeEeEeEeGgGgGgGgHh" +
+		"..IiIiIiIiI...JjKk.LlLlLlLl..NnNnNnn..OoOoOo..RrRrRrSsSsSsSsTtTt..UuUuUuUuUuUuWwYyYZzZzZzs...................." +
+		"............Oo.............Uu............................AaIiOoUuUuUuUuUu.AaAa....GgKkOoOo..j...Gg..NnAa....Aa" +
+		"AaEeEeIiIiOoOoRrRrUuUuSsTt..Hh......AaEeOoOoOoOoYy............................"
+	latinAdditionalFoldsStart = 0x1E00
+	latinAdditionalFolds      = "AaBbBbBbCcDdDdDdDdDdEeEeEeEeEeFfGgHhHhHhHhHhIiIiKkKkKkLlLlLlLlMmMmMmNnNnNnNnOoOoOoOoPpPpRrRrRr" +
+		"RrSsSsSsSsSsTtTtTtTtUuUuUuUuUuVvVvWwWwWwWwWwXxXxYyZzZzZzhtwyas....AaAaAaAaAaAaAaAaAaAaAaAaEeEeEeEeEeEeEeEeIi" +
+		"IiOoOoOoOoOoOoOoOoOoOoOoOoUuUuUuUuUuUuUuYyYyYyYy......"
+)
+
+//nolint:gochecknoglobals
+var latinLigatureFolds = map[rune]string{
+	'Ĳ': "IJ", 'ĳ': "ij", 'Ǆ': "DZ", 'ǅ': "Dz", 'ǆ': "dz", 'Ǉ': "LJ", 'ǈ': "Lj", 'ǉ': "lj",
+	'Ǌ': "NJ", 'ǋ': "Nj", 'ǌ': "nj", 'Ǳ': "DZ", 'ǲ': "Dz", 'ǳ': "dz",
+}
+
+var (
+	mkdocsInvalidPattern   = regexp.MustCompile(`[^\w\s-]`)
+	mkdocsSeparatorPattern = regexp.MustCompile(`[-\s]+`)
+	mkdocsCountPattern     = regexp.MustCompile(`^(.*)_([0-9]+)$`)
+)
+
+// mkdocs follows the default slugify of the Python-Markdown toc extension used by MkDocs
+type mkdocs struct{}
+
+func (mkdocs) Slug(heading string) string {
+	normalised := foldASCII(heading)
+	normalised = mkdocsInvalidPattern.ReplaceAllString(normalised, "")
+	normalised = strings.ToLower(strings.TrimSpace(normalised))
+
+	return mkdocsSeparatorPattern.ReplaceAllString(normalised, "-")
+}
+
+// Unique appends _1, _2, ... and increments an existing numeric suffix, as Python-Markdown does
+func (mkdocs) Unique(anchor string, seen map[string]int) string {
+	unique := anchor
+
+	for {
+		if _, taken := seen[unique]; !taken && unique != "" {
+			break
+		}
+
+		if match := mkdocsCountPattern.FindStringSubmatch(unique); match != nil {
+			count, _ := strconv.Atoi(match[2])
+			unique = fmt.Sprintf("%s_%d", match[1], count+1)
+		} else {
+			unique += "_1"
+		}
+	}
+
+	seen[unique] = 0
+
+	return unique
+}
+
+func (m mkdocs) Normalise(anchor string) string {
+	return m.Slug(percentDecode(anchor))
+}
+
+// foldASCII approximates NFKD decomposition followed by dropping everything that is not ASCII
+func foldASCII(s string) string {
+	var builder strings.Builder
+
+	for _, r := range s {
+		switch {
+		case r <= unicode.MaxASCII:
+			builder.WriteRune(r)
+		case latinLigatureFolds[r] != "":
+			builder.WriteString(latinLigatureFolds[r])
+		case r >= latinFoldsStart && int(r) < latinFoldsStart+len(latinFolds):
+			writeFold(&builder, latinFolds[r-latinFoldsStart])
+		case r >= latinAdditionalFoldsStart && int(r) < latinAdditionalFoldsStart+len(latinAdditionalFolds):
+			writeFold(&builder, latinAdditionalFolds[r-latinAdditionalFoldsStart])
+		}
+	}
+
+	return builder.String()
+}
+
+func writeFold(builder *strings.Builder, folded byte) {
+	if folded != '.' {
+		builder.WriteByte(folded)
+	}
+}
@@ -0,0 +1,802 @@
+package commonmark
+
+import (
+	"regexp"
+	"strings"
+)
+
+type nodeType int
+
+const (
+	documentNode nodeType = iota
+	blockQuoteNode
+	listNode
+	itemNode
+	paragraphNode
+	headingNode
+	thematicBreakNode
+	codeBlockNode
+	htmlBlockNode
+)
+
+type listData struct {
+	ordered      bool
+	bulletChar   byte
+	delimiter    byte
+	markerOffset int
+	padding      int
+}
+
+type node struct {
+	kind     nodeType
+	parent   *node
+	children []*node
+	open     bool
+	lines    []segment
+
+	level int // Headings
+
+	fenced      bool // Code blocks
+	fenceChar   byte
+	fenceLength int
+	fenceOffset int
+
+	htmlType int // HTML blocks
+
+	list listData // Lists and list items
+}
+
+func (n *node) lastChild() *node {
+	if len(n.children) == 0 {
+		return nil
+	}
+
+	return n.children[len(n.children)-1]
+}
+
+func (n *node) acceptsLines() bool {
+	return n.kind == paragraphNode || n.kind == codeBlockNode || n.kind == htmlBlockNode
+}
+
+func (n *node) canContain(kind nodeType) bool {
+	switch n.kind {
+	case documentNode, blockQuoteNode, itemNode:
+		return kind != itemNode
+	case listNode:
+		return kind == itemNode
+	case paragraphNode, headingNode, thematicBreakNode, codeBlockNode, htmlBlockNode:
+		return false
+	}
+
+	return false
+}
+
+type continuation int
+
+const (
+	matched continuation = iota
+	notMatched
+	lineConsumed
+)
+
+type blockStart int
+
+const (
+	noStart blockStart = iota
+	containerStart
+	leafStart
+)
+
+const codeIndent = 4
+
+var (
+	maybeSpecialPattern   = regexp.MustCompile(`^[#` + "`" + `~*+_=<>0-9-]`)
+	atxHeadingPattern     = regexp.MustCompile(`^#{1,6}(?:[ \t]+|$)`)
+	atxClosingPattern     = regexp.MustCompile(`(?:^|[ \t]+)#+[ \t]*$`)
+	codeFencePattern      = regexp.MustCompile("^`{3,}[^`]*$|^~{3,}")
+	closingFencePattern   = regexp.MustCompile("^(?:`{3,}|~{3,})[ \t]*$")
+	setextHeadingPattern  = regexp.MustCompile(`^(?:=+|-+)[ \t]*$`)
+	thematicBreakPattern  = regexp.MustCompile(`^(?:\*[ \t]*){3,}$|^(?:_[ \t]*){3,}$|^(?:-[ \t]*){3,}$`)
+	bulletListPattern     = regexp.MustCompile(`^[*+-]`)
+	orderedListPattern    = regexp.MustCompile(`^(\d{1,9})([.)])`)
+	htmlBlockOpenPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`^<(?i:script|pre|textarea|style)(?:\s|>|$)`),
+		regexp.MustCompile(`^<!--`),
+		regexp.MustCompile(`^<[?]`),
+		regexp.MustCompile(`^<![A-Za-z]`),
+		regexp.MustCompile(`^<!\[CDATA\[`),
+		regexp.MustCompile(`^<[/]?(?i:address|article|aside|base|basefont|blockquote|body|caption|center|col|colgroup|dd|` +
+			`details|dialog|dir|div|dl|dt|fieldset|figcaption|figure|footer|form|frame|frameset|h[123456]|head|header|hr|` +
+			`html|iframe|legend|li|link|main|menu|menuitem|nav|noframes|ol|optgroup|option|p|param|search|section|` +
+			`summary|table|tbody|td|tfoot|th|thead|title|tr|track|ul)(?:\s|[/]?[>]|$)`),
+		regexp.MustCompile(`^(?:` + openTag + `|` + closeTag + `)\s*$`),
+	}
+	htmlBlockClosePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`</(?i:script|pre|textarea|style)>`),
+		regexp.MustCompile(`-->`),
+		regexp.MustCompile(`\?>`),
+		regexp.MustCompile(`>`),
+		regexp.MustCompile(`\]\]>`),
+	}
+)
+
+type blockParser struct {
+	document             *node
+	tip                  *node
+	oldTip               *node
+	lastMatchedContainer *node
+
+	line       string
+	lineNumber int
+
+	offset               int
+	column               int
+	nextNonspace         int
+	nextNonspaceColumn   int
+	indent               int
+	indented             bool
+	blank                bool
+	partiallyConsumedTab bool
+	allClosed            bool
+
+	references  map[string]bool
+	definitions []Definition
+	leaves      []*node // Paragraphs, headings and HTML blocks in document order
+}
+
+func newBlockParser() *blockParser {
+	document := &node{kind: documentNode, open: true}
+
+	return &blockParser{
+		document:    document,
+		tip:         document,
+		oldTip:      document,
+		references:  make(map[string]bool),
+		definitions: []Definition{},
+		leaves:      []*node{},
+	}
+}
+
+func (p *blockParser) addChild(kind nodeType) *node {
+	for !p.tip.canContain(kind) {
+		p.finalize(p.tip)
+	}
+
+	child := &node{kind: kind, parent: p.tip, open: true}
+	p.tip.children = append(p.tip.children, child)
+	p.tip = child
+
+	return child
+}
+
+func (p *blockParser) addLine() {
+	// Code block content is not needed, so a partially consumed tab is simply skipped
+	if p.partiallyConsumedTab {
+		p.offset++
+	}
+
+	p.tip.lines = append(p.tip.lines, segment{
+		text:   p.line[p.offset:],
+		line:   p.lineNumber,
+		offset: p.offset,
+	})
+}
+
+func (p *blockParser) closeUnmatchedBlocks() {
+	if p.allClosed {
+		return
+	}
+
+	for p.oldTip != p.lastMatchedContainer {
+		parent := p.oldTip.parent
+		p.finalize(p.oldTip)
+		p.oldTip = parent
+	}
+
+	p.allClosed = true
+}
+
+func (p *blockParser) finalize(block *node) {
+	block.open = false
+
+	if block.kind == paragraphNode {
+		p.extractDefinitions(block)
+	}
+
+	if (block.kind == paragraphNode && len(block.lines) > 0) || block.kind == headingNode || block.kind == htmlBlockNode {
+		p.leaves = append(p.leaves, block)
+	}
+
+	p.tip = block.parent
+}
+
+// extractDefinitions removes link reference definitions from the beginning of a paragraph
+func (p *blockParser) extractDefinitions(paragraph *node) {
+	content := newText(paragraph.lines)
+
+	consumed := 0
+	for consumed < len(content.content) {
+		definition, length := parseDefinition(content, consumed)
+		if length == 0 {
+			break
+		}
+
+		definition.Duplicate = p.references[definition.Label]
+		p.references[definition.Label] = true
+		p.definitions = append(p.definitions, definition)
+
+		consumed += length
+	}
+
+	if consumed == 0 {
+		return
+	}
+
+	// Definitions always end at the end of a line
+	remaining := content.lineIndex(consumed)
+	if consumed >= len(content.content) {
+		remaining = len(paragraph.lines)
+	}
+
+	paragraph.lines = paragraph.lines[remaining:]
+}
+
+func (p *blockParser) finish() {
+	for p.tip != nil {
+		p.finalize(p.tip)
+	}
+}
+
+func (p *blockParser) advanceOffset(count int, columns bool) {
+	for count > 0 && p.offset < len(p.line) {
+		if p.line[p.offset] == '\t' {
+			charsToTab := codeIndent - (p.column % codeIndent)
+			if columns {
+				p.partiallyConsumedTab = charsToTab > count
+				charsToAdvance := min(count, charsToTab)
+				p.column += charsToAdvance
+
+				if !p.partiallyConsumedTab {
+					p.offset++
+				}
+
+				count -= charsToAdvance
+			} else {
+				p.partiallyConsumedTab = false
+				p.column += charsToTab
+				p.offset++
+				count--
+			}
+		} else {
+			p.partiallyConsumedTab = false
+			p.offset++
+			p.column++
+			count--
+		}
+	}
+}
+
+func (p *blockParser) advanceNextNonspace() {
+	p.offset = p.nextNonspace
+	p.column = p.nextNonspaceColumn
+	p.partiallyConsumedTab = false
+}
+
+func (p *blockParser) findNextNonspace() {
+	i := p.offset
+	cols := p.column
+
+	for ; i < len(p.line) && (p.line[i] == ' ' || p.line[i] == '\t'); i++ {
+		if p.line[i] == '\t' {
+			cols += codeIndent - (cols % codeIndent)
+		} else {
+			cols++
+		}
+	}
+
+	p.blank = i >= len(p.line)
+	p.nextNonspace = i
+	p.nextNonspaceColumn = cols
+	p.indent = p.nextNonspaceColumn - p.column
+	p.indented = p.indent >= codeIndent
+}
+
+func (p *blockParser) peek(offset int) byte {
+	if offset < len(p.line) {
+		return p.line[offset]
+	}
+
+	return 0
+}
+
+func (p *blockParser) rest() string {
+	return p.line[p.nextNonspace:]
+}
+
+//nolint:cyclop,funlen // mirrors the parsing strategy in the CommonMark spec appendix
+func (p *blockParser) incorporateLine(line string) {
+	p.line = line
+	p.lineNumber++
+	p.offset = 0
+	p.column = 0
+	p.blank = false
+	p.partiallyConsumedTab = false
+
+	container := p.document
+	p.oldTip = p.tip
+
+	for {
+		last := container.lastChild()
+		if last == nil || !last.open {
+			break
+		}
+
+		container = last
+
+		p.findNextNonspace()
+
+		result := p.continueBlock(container)
+		if result == lineConsumed {
+			return
+		}
+
+		if result == notMatched {
+			container = container.parent
+
+			break
+		}
+	}
+
+	p.allClosed = container == p.oldTip
+	p.lastMatchedContainer = container
+
+	matchedLeaf := container.kind != paragraphNode && container.acceptsLines()
+
+	for !matchedLeaf {
+		p.findNextNonspace()
+
+		if !p.indented && !maybeSpecialPattern.MatchString(p.rest()) {
+			p.advanceNextNonspace()
+
+			break
+		}
+
+		started := p.startBlock(container)
+		if started == noStart {
+			p.advanceNextNonspace()
+
+			break
+		}
+
+		container = p.tip
+		if started == leafStart {
+			matchedLeaf = true
+		}
+	}
+
+	// Lazy paragraph continuation
+	if !p.allClosed && !p.blank && p.tip.kind == paragraphNode {
+		p.addLine()
+
+		return
+	}
+
+	p.closeUnmatchedBlocks()
+
+	switch {
+	case container.acceptsLines():
+		p.addLine()
+
+		if container.kind == htmlBlockNode && container.htmlType >= 1 && container.htmlType <= 5 &&
+			htmlBlockClosePatterns[container.htmlType-1].MatchString(p.line[p.offset:]) {
+			p.finalize(container)
+		}
+	case p.offset < len(p.line) && !p.blank:
+		p.addChild(paragraphNode)
+		p.advanceNextNonspace()
+		p.addLine()
+	}
+}
+
+//nolint:cyclop // one case per block type
+func (p *blockParser) continueBlock(container *node) continuation {
+	switch container.kind {
+	case documentNode, listNode:
+		return matched
+	case blockQuoteNode:
+		if !p.indented && p.peek(p.nextNonspace) == '>' {
+			p.advanceNextNonspace()
+			p.advanceOffset(1, false)
+
+			if c := p.peek(p.offset); c == ' ' || c == '\t' {
+				p.advanceOffset(1, true)
+			}
+
+			return matched
+		}
+
+		return notMatched
+	case itemNode:
+		if p.blank {
+			if len(container.children) == 0 {
+				// A list item can begin with at most one blank line
+				return notMatched
+			}
+
+			p.advanceNextNonspace()
+
+			return matched
+		}
+
+		if p.indent >= container.list.markerOffset+container.list.padding {
+			p.advanceOffset(container.list.markerOffset+container.list.padding, true)
+
+			return matched
+		}
+
+		return notMatched
+	case headingNode, thematicBreakNode:
+		return notMatched
+	case codeBlockNode:
+		return p.continueCodeBlock(container)
+	case htmlBlockNode:
+		if p.blank && (container.htmlType == 6 || container.htmlType == 7) {
+			return notMatched
+		}
+
+		return matched
+	case paragraphNode:
+		if p.blank {
+			return notMatched
+		}
+
+		return matched
+	}
+
+	return notMatched
+}
+
+func (p *blockParser) continueCodeBlock(container *node) continuation {
+	if container.fenced {
+		rest := p.rest()
+		if p.indent <= 3 && len(rest) > 0 && rest[0] == container.fenceChar {
+			if fence := closingFencePattern.FindString(rest); fence != "" {
+				fenceLength := len(strings.TrimRight(fence, " \t"))
+				if fenceLength >= container.fenceLength {
+					p.finalize(container)
+
+					return lineConsumed
+				}
+			}
+		}
+
+		// Skip optional spaces of fence offset
+		for i := container.fenceOffset; i > 0 && p.peek(p.offset) == ' '; i-- {
+			p.advanceOffset(1, true)
+		}
+
+		return matched
+	}
+
+	switch {
+	case p.indent >= codeIndent:
+		p.advanceOffset(codeIndent, true)
+	case p.blank:
+		p.advanceNextNonspace()
+	default:
+		return notMatched
+	}
+
+	return matched
+}
+
+func (p *blockParser) startBlock(container *node) blockStart {
+	starts := []func(*node) blockStart{
+		p.startBlockQuote,
+		p.startATXHeading,
+		p.startFencedCode,
+		p.startHTMLBlock,
+		p.startSetextHeading,
+		p.startThematicBreak,
+		p.startListItem,
+		p.startIndentedCode,
+	}
+
+	for _, start := range starts {
+		if result := start(container); result != noStart {
+			return result
+		}
+	}
+
+	return noStart
+}
+
+func (p *blockParser) startBlockQuote(_ *node) blockStart {
+	if p.indented || p.peek(p.nextNonspace) != '>' {
+		return noStart
+	}
+
+	p.advanceNextNonspace()
+	p.advanceOffset(1, false)
+
+	if c := p.peek(p.offset); c == ' ' || c == '\t' {
+		p.advanceOffset(1, true)
+	}
+
+	p.closeUnmatchedBlocks()
+	p.addChild(blockQuoteNode)
+
+	return containerStart
+}
+
+func (p *blockParser) startATXHeading(_ *node) blockStart {
+	if p.indented {
+		return noStart
+	}
+
+	match := atxHeadingPattern.FindString(p.rest())
+	if match == "" {
+		return noStart
+	}
+
+	p.advanceNextNonspace()
+	p.advanceOffset(len(match), false)
+	p.closeUnmatchedBlocks()
+
+	heading := p.addChild(headingNode)
+	heading.level = len(strings.TrimRight(match, " \t"))
+
+	content := p.line[p.offset:]
+	content = atxClosingPattern.ReplaceAllString(content, "")
+	content = strings.TrimRight(content, " \t")
+
+	heading.lines = []segment{{text: content, line: p.lineNumber, offset: p.offset}}
+
+	p.advanceOffset(len(p.line)-p.offset, false)
+
+	return leafStart
+}
+
+func (p *blockParser) startFencedCode(_ *node) blockStart {
+	if p.indented {
+		return noStart
+	}
+
+	match := codeFencePattern.FindString(p.rest())
+	if match == "" {
+		return noStart
+	}
+
+	fenceLength := 0
+	for fenceLength < len(match) && match[fenceLength] == match[0] {
+		fenceLength++
+	}
+
+	p.closeUnmatchedBlocks()
+
+	code := p.addChild(codeBlockNode)
+	code.fenced = true
+	code.fenceChar = match[0]
+	code.fenceLength = fenceLength
+	code.fenceOffset = p.indent
+
+	p.advanceNextNonspace()
+	p.advanceOffset(len(p.line)-p.offset, false)
+
+	return leafStart
+}
+
+func (p *blockParser) startHTMLBlock(container *node) blockStart {
+	if p.indented || p.peek(p.nextNonspace) != '<' {
+		return noStart
+	}
+
+	rest := p.rest()
+
+	for i, pattern := range htmlBlockOpenPatterns {
+		htmlType := i + 1
+
+		// Type 7 cannot interrupt a paragraph
+		if htmlType == 7 && (container.kind == paragraphNode || (!p.allClosed && p.tip.kind == paragraphNode)) {
+			continue
+		}
+
+		if pattern.MatchString(rest) {
+			p.closeUnmatchedBlocks()
+
+			block := p.addChild(htmlBlockNode)
+			block.htmlType = htmlType
+
+			// The opening line belongs to the block, indentation included
+			return leafStart
+		}
+	}
+
+	return noStart
+}
+
+func (p *blockParser) startSetextHeading(container *node) blockStart {
+	if p.indented || container.kind != paragraphNode || !setextHeadingPattern.MatchString(p.rest()) {
+		return noStart
+	}
+
+	p.closeUnmatchedBlocks()
+
+	// Definitions at the start of the paragraph are not part of the heading
+	p.extractDefinitions(container)
+
+	if len(container.lines) == 0 {
+		return noStart
+	}
+
+	heading := &node{kind: headingNode, parent: container.parent, open: true, lines: container.lines}
+	if p.peek(p.nextNonspace) == '=' {
+		heading.level = 1
+	} else {
+		heading.level = 2
+	}
+
+	// Replace the paragraph with the heading
+	siblings := container.parent.children
+	siblings[len(siblings)-1] = heading
+	p.tip = heading
+
+	p.advanceOffset(len(p.line)-p.offset, false)
+
+	return leafStart
+}
+
+func (p *blockParser) startThematicBreak(_ *node) blockStart {
+	if p.indented || !thematicBreakPattern.MatchString(p.rest()) {
+		return noStart
+	}
+
+	p.closeUnmatchedBlocks()
+	p.addChild(thematicBreakNode)
+	p.advanceOffset(len(p.line)-p.offset, false)
+
+	return leafStart
+}
+
+func (p *blockParser) startListItem(container *node) blockStart {
+	data, ok := p.parseListMarker(container)
+	if !ok {
+		return noStart
+	}
+
+	p.closeUnmatchedBlocks()
+
+	// Add a list if needed
+	if p.tip.kind != listNode || !sameListType(p.tip.list, data) {
+		list := p.addChild(listNode)
+		list.list = data
+	}
+
+	item := p.addChild(itemNode)
+	item.list = data
+
+	return containerStart
+}
+
+func sameListType(list, item listData) bool {
+	return list.ordered == item.ordered && list.delimiter == item.delimiter && list.bulletChar == item.bulletChar
+}
+
+//nolint:cyclop // follows the list item rules of the spec closely
+func (p *blockParser) parseListMarker(container *node) (listData, bool) {
+	if p.indent >= codeIndent {
+		return listData{}, false
+	}
+
+	rest := p.rest()
+
+	var (
+		data   listData
+		marker string
+	)
+
+	if match := bulletListPattern.FindString(rest); match != "" {
+		data.bulletChar = match[0]
+		marker = match
+	} else if match := orderedListPattern.FindStringSubmatch(rest); match != nil &&
+		(container.kind != paragraphNode || strings.TrimLeft(match[1], "0") == "1") {
+		data.ordered = true
+		data.delimiter = match[2][0]
+		marker = match[0]
+	} else {
+		return listData{}, false
+	}
+
+	// The marker must be followed by whitespace or the end of the line
+	if c := p.peek(p.nextNonspace + len(marker)); c != 0 && c != ' ' && c != '\t' {
+		return listData{}, false
+	}
+
+	// An empty list item cannot interrupt a paragraph
+	if container.kind == paragraphNode && strings.TrimSpace(rest[len(marker):]) == "" {
+		return listData{}, false
+	}
+
+	p.advanceNextNonspace()
+	p.advanceOffset(len(marker), true)
+
+	spacesStartColumn := p.column
+	spacesStartOffset := p.offset
+
+	for {
+		p.advanceOffset(1, true)
+
+		c := p.peek(p.offset)
+		if p.column-spacesStartColumn >= 5 || (c != ' ' && c != '\t') {
+			break
+		}
+	}
+
+	blankItem := p.offset >= len(p.line)
+	spacesAfterMarker := p.column - spacesStartColumn
+
+	if spacesAfterMarker >= 5 || spacesAfterMarker < 1 || blankItem {
+		data.padding = len(marker) + 1
+		p.column = spacesStartColumn
+		p.offset = spacesStartOffset
+
+		if c := p.peek(p.offset); c == ' ' || c == '\t' {
+			p.advanceOffset(1, true)
+		}
+	} else {
+		data.padding = len(marker) + spacesAfterMarker
+	}
+
+	data.markerOffset = p.indent
+
+	return data, true
+}
+
+func (p *blockParser) startIndentedCode(_ *node) blockStart {
+	if !p.indented || p.tip.kind == paragraphNode || p.blank {
+		return noStart
+	}
+
+	p.advanceOffset(codeIndent, true)
+	p.closeUnmatchedBlocks()
+	p.addChild(codeBlockNode)
+
+	return leafStart
+}
+
+// walkInlines parses the inline content of paragraphs and headings once all definitions are known,
+// HTML blocks are collected as they are
+func (p *blockParser) walkInlines(document *Document) {
+	for _, leaf := range p.leaves {
+		if leaf.kind == htmlBlockNode {
+			source := newText(leaf.lines)
+			document.HTML = append(document.HTML, HTML{Content: source.content, Block: true, source: source, start: 0})
+
+			continue
+		}
+
+		lines := leaf.lines
+		if len(lines) > 0 {
+			// Trailing whitespace of the final line is not part of the content
+			lines = append([]segment{}, lines...)
+			last := &lines[len(lines)-1]
+			last.text = strings.TrimRight(last.text, " \t")
+		}
+
+		source := newText(lines)
+		parser := newInlineParser(source, p.references)
+		parser.parse()
+
+		document.Links = append(document.Links, parser.links...)
+		document.References = append(document.References, parser.referenceLinks...)
+		document.HTML = append(document.HTML, parser.html...)
+
+		if leaf.kind == headingNode {
+			document.Headings = append(document.Headings, Heading{
+				Level:    leaf.level,
+				Text:     parser.plainText(),
+				Position: source.position(0),
+			})
+		}
+	}
+}
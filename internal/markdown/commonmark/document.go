@@ -0,0 +1,104 @@
+// Package commonmark parses Markdown into the parts relcheck needs: headings, links, link reference
+// definitions, references and raw HTML. Block and inline structure follow the CommonMark spec, so that
+// for example code spans, fenced code blocks and HTML blocks never yield links.
+package commonmark
+
+import (
+	"strings"
+)
+
+type Position struct {
+	Line   int
+	Column int
+}
+
+type Heading struct {
+	Level    int
+	Text     string // Plain text content, as rendered
+	Position Position
+}
+
+// Link is an inline link or image such as [text](./path.md "title")
+type Link struct {
+	Destination string
+	Title       string
+	Image       bool
+	Position    Position // Start of the destination
+	End         Position // End of the destination, exclusive
+}
+
+type ReferenceKind int
+
+const (
+	Full      ReferenceKind = iota // [text][label]
+	Collapsed                      // [label][]
+	Shortcut                       // [label]
+)
+
+type Reference struct {
+	Label    string // Normalised label
+	Kind     ReferenceKind
+	Defined  bool
+	Image    bool
+	Position Position // Start of the label
+	End      Position // End of the label, exclusive
+}
+
+// Definition is a link reference definition such as [label]: ./path.md "title"
+type Definition struct {
+	Label       string // Normalised label
+	Destination string
+	Title       string
+	Duplicate   bool     // Only the first definition of a label is used
+	Position    Position // Start of the destination
+	End         Position // End of the destination, exclusive
+}
+
+type Document struct {
+	Lines       []string
+	Headings    []Heading
+	Links       []Link
+	References  []Reference
+	Definitions []Definition
+	HTML        []HTML
+}
+
+func Parse(source string) *Document {
+	lines := splitLines(source)
+
+	parser := newBlockParser()
+	for _, line := range lines {
+		parser.incorporateLine(line)
+	}
+
+	parser.finish()
+
+	document := &Document{
+		Lines:       lines,
+		Definitions: parser.definitions,
+	}
+
+	parser.walkInlines(document)
+
+	return document
+}
+
+func splitLines(source string) []string {
+	if source == "" {
+		return []string{}
+	}
+
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\r", "\n")
+	source = strings.TrimSuffix(source, "\n")
+
+	return strings.Split(source, "\n")
+}
+
+// NormaliseLabel matches reference labels case-insensitively with collapsed whitespace
+func NormaliseLabel(label string) string {
+	folded := strings.ToLower(strings.ToUpper(strings.Join(strings.Fields(label), " ")))
+
+	// Unicode case folding maps ß to ss, which simple case mapping does not
+	return strings.ReplaceAll(folded, "ß", "ss")
+}
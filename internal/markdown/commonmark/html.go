@@ -0,0 +1,108 @@
+package commonmark
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// HTML is an HTML block or a piece of inline HTML such as <a id="anchor"></a>
+type HTML struct {
+	Content string
+	Block   bool
+	source  text
+	start   int // Offset of Content within source
+}
+
+// Attribute is an attribute of an opening tag within HTML, such as id="anchor" of <a id="anchor">
+type Attribute struct {
+	Tag      string   // Lowercase tag name
+	Name     string   // Lowercase attribute name
+	Value    string   // Value with character references decoded
+	Position Position // Start of the value, or of the name when there is no value
+	End      Position // End of the raw value or the name, exclusive
+	html     HTML
+	start    int // Offset of the raw value within the HTML
+}
+
+// PositionOf returns the position of a byte offset within the raw, undecoded value
+func (a Attribute) PositionOf(offset int) Position {
+	return a.html.Position(a.start + offset)
+}
+
+var (
+	openTagPattern   = regexp.MustCompile(`^<(` + tagName + `)`)
+	attributePattern = regexp.MustCompile(`\s+(` + attributeName + `)(?:\s*=\s*(` + attributeValue + `))?`)
+)
+
+// Position returns the position of a byte offset within Content
+func (h HTML) Position(offset int) Position {
+	return h.source.position(h.start + offset)
+}
+
+// Attributes returns the attributes of the opening tags within the HTML, skipping comments
+func (h HTML) Attributes() []Attribute {
+	attributes := []Attribute{}
+
+	for offset := 0; offset < len(h.Content); {
+		next := strings.IndexByte(h.Content[offset:], '<')
+		if next < 0 {
+			break
+		}
+
+		offset += next
+		rest := h.Content[offset:]
+
+		tag := htmlTagPattern.FindString(rest)
+		if tag == "" {
+			offset++
+
+			continue
+		}
+
+		// Comments, closing tags and declarations have no attributes
+		if match := openTagPattern.FindStringSubmatch(tag); match != nil {
+			attributes = append(attributes, h.tagAttributes(tag, strings.ToLower(match[1]), offset)...)
+		}
+
+		offset += len(tag)
+	}
+
+	return attributes
+}
+
+func (h HTML) tagAttributes(tag, name string, offset int) []Attribute {
+	attributes := []Attribute{}
+
+	// The tag name is followed by the attributes, the regular expression stops before /> and >
+	afterName := 1 + len(name)
+
+	for _, match := range attributePattern.FindAllStringSubmatchIndex(tag[afterName:], -1) {
+		attribute := Attribute{
+			Tag:      name,
+			Name:     strings.ToLower(tag[afterName+match[2] : afterName+match[3]]),
+			Value:    "",
+			Position: h.Position(offset + afterName + match[2]),
+			End:      h.Position(offset + afterName + match[3]),
+			html:     h,
+			start:    offset + afterName + match[2],
+		}
+
+		if match[4] >= 0 {
+			valueStart, valueEnd := afterName+match[4], afterName+match[5]
+			if quote := tag[valueStart]; quote == '"' || quote == '\'' {
+				valueStart++
+				valueEnd--
+			}
+
+			attribute.Value = html.UnescapeString(tag[valueStart:valueEnd])
+			attribute.Position = h.Position(offset + valueStart)
+			attribute.End = h.Position(offset + valueEnd)
+			attribute.start = offset + valueStart
+		}
+
+		attributes = append(attributes, attribute)
+	}
+
+	return attributes
+}
@@ -0,0 +1,834 @@
+package commonmark
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type inlineKind int
+
+const (
+	textInline inlineKind = iota
+	codeInline
+	htmlInline
+	softBreakInline
+	linkOpenInline
+	linkCloseInline
+	imageOpenInline
+	imageCloseInline
+)
+
+type inline struct {
+	kind    inlineKind
+	literal string
+}
+
+type delimiter struct {
+	char       byte
+	numDelims  int
+	origDelims int
+	node       int // Index of the text node holding the delimiter run
+	canOpen    bool
+	canClose   bool
+	previous   *delimiter
+	next       *delimiter
+}
+
+type bracket struct {
+	node              int // Index of the text node holding [ or ![
+	labelStart        int // Offset right after the opening bracket
+	image             bool
+	active            bool
+	bracketAfter      bool
+	previous          *bracket
+	previousDelimiter *delimiter
+}
+
+type openersBottomKey struct {
+	char    byte
+	canOpen bool
+	mod     int
+}
+
+const (
+	tagName             = `[A-Za-z][A-Za-z0-9-]*`
+	attributeName       = `[a-zA-Z_:][a-zA-Z0-9_.:-]*`
+	unquotedValue       = "[^\"'=<>`\\x00-\\x20]+"
+	attributeValue      = `(?:` + unquotedValue + `|'[^']*'|"[^"]*")`
+	attributeValueSpec  = `(?:\s*=\s*` + attributeValue + `)`
+	attribute           = `(?:\s+` + attributeName + attributeValueSpec + `?)`
+	openTag             = `<` + tagName + attribute + `*\s*/?>`
+	closeTag            = `</` + tagName + `\s*[>]`
+	htmlComment         = `<!-->|<!--->|(?s:<!--.*?-->)`
+	processingInstr     = `(?s:[<][?].*?[?][>])`
+	declaration         = `<![A-Za-z]+[^>]*>`
+	cdata               = `(?s:<!\[CDATA\[.*?\]\]>)`
+	maxLinkLabelLength  = 999
+	maxParenthesesDepth = 32
+)
+
+var (
+	htmlTagPattern       = regexp.MustCompile(`^(?:` + openTag + `|` + closeTag + `|` + htmlComment + `|` + processingInstr + `|` + declaration + `|` + cdata + `)`)
+	autolinkPattern      = regexp.MustCompile(`^<[A-Za-z][A-Za-z0-9.+-]{1,31}:[^<>\x00-\x20]*>`)
+	emailAutolinkPattern = regexp.MustCompile(`^<[a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*>`)
+	entityPattern        = regexp.MustCompile(`^&(?:#[xX][a-fA-F0-9]{1,6}|#[0-9]{1,7}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+	escapeOrEntity       = regexp.MustCompile(`\\[!"#$%&'()*+,./:;<=>?@\[\\\]^_` + "`" + `{|}~-]|&(?:#[xX][a-fA-F0-9]{1,6}|#[0-9]{1,7}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+	linkLabelPattern     = regexp.MustCompile(`^\[(?s:[^\\\[\]]|\\.){0,999}\]`)
+)
+
+type inlineParser struct {
+	source     text
+	subject    string
+	pos        int
+	nodes      []inline
+	delimiters *delimiter
+	brackets   *bracket
+	references map[string]bool
+
+	links          []Link
+	referenceLinks []Reference
+	html           []HTML
+}
+
+func newInlineParser(source text, references map[string]bool) *inlineParser {
+	return &inlineParser{
+		source:     source,
+		subject:    source.content,
+		references: references,
+	}
+}
+
+func isASCIIPunctuation(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func unescapeString(s string) string {
+	if !strings.ContainsAny(s, "\\&") {
+		return s
+	}
+
+	return escapeOrEntity.ReplaceAllStringFunc(s, func(match string) string {
+		if match[0] == '\\' {
+			return match[1:]
+		}
+
+		return html.UnescapeString(match)
+	})
+}
+
+func (p *inlineParser) peek() byte {
+	if p.pos < len(p.subject) {
+		return p.subject[p.pos]
+	}
+
+	return 0
+}
+
+func (p *inlineParser) addText(literal string) {
+	p.nodes = append(p.nodes, inline{kind: textInline, literal: literal})
+}
+
+// spnl skips spaces and tabs with at most one newline
+func (p *inlineParser) spnl() {
+	p.skipSpaces()
+
+	if p.peek() == '\n' {
+		p.pos++
+		p.skipSpaces()
+	}
+}
+
+func (p *inlineParser) skipSpaces() {
+	for p.pos < len(p.subject) && (p.subject[p.pos] == ' ' || p.subject[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *inlineParser) parse() {
+	for p.pos < len(p.subject) {
+		p.parseInline()
+	}
+
+	p.processEmphasis(nil)
+}
+
+//nolint:cyclop // one case per special character
+func (p *inlineParser) parseInline() {
+	switch c := p.subject[p.pos]; c {
+	case '\n':
+		p.parseNewline()
+	case '\\':
+		p.parseBackslash()
+	case '`':
+		p.parseBackticks()
+	case '*', '_':
+		p.handleDelimiter(c)
+	case '[':
+		p.pos++
+		p.addText("[")
+		p.addBracket(p.pos, false)
+	case '!':
+		p.pos++
+		if p.peek() == '[' {
+			p.pos++
+			p.addText("![")
+			p.addBracket(p.pos, true)
+		} else {
+			p.addText("!")
+		}
+	case ']':
+		p.parseCloseBracket()
+	case '<':
+		p.parseLessThan()
+	case '&':
+		if match := entityPattern.FindString(p.subject[p.pos:]); match != "" {
+			p.pos += len(match)
+			p.addText(html.UnescapeString(match))
+		} else {
+			p.pos++
+			p.addText("&")
+		}
+	default:
+		p.parseString()
+	}
+}
+
+func (p *inlineParser) parseString() {
+	end := p.pos + 1
+	for end < len(p.subject) && strings.IndexByte("\n\\`*_[]!<&", p.subject[end]) < 0 {
+		end++
+	}
+
+	p.addText(p.subject[p.pos:end])
+	p.pos = end
+}
+
+func (p *inlineParser) parseNewline() {
+	p.pos++
+
+	// Trailing spaces before a line break are not part of the text
+	if last := len(p.nodes) - 1; last >= 0 && p.nodes[last].kind == textInline {
+		p.nodes[last].literal = strings.TrimRight(p.nodes[last].literal, " ")
+	}
+
+	p.nodes = append(p.nodes, inline{kind: softBreakInline, literal: "\n"})
+	p.skipSpaces()
+}
+
+func (p *inlineParser) parseBackslash() {
+	p.pos++
+
+	switch c := p.peek(); {
+	case c == '\n':
+		p.pos++
+		p.nodes = append(p.nodes, inline{kind: softBreakInline, literal: "\n"})
+	case c != 0 && isASCIIPunctuation(c):
+		p.pos++
+		p.addText(string(c))
+	default:
+		p.addText("\\")
+	}
+}
+
+func (p *inlineParser) parseBackticks() {
+	start := p.pos
+	for p.pos < len(p.subject) && p.subject[p.pos] == '`' {
+		p.pos++
+	}
+
+	ticks := p.subject[start:p.pos]
+	afterOpen := p.pos
+
+	for search := afterOpen; search < len(p.subject); {
+		next := strings.IndexByte(p.subject[search:], '`')
+		if next < 0 {
+			break
+		}
+
+		runStart := search + next
+		runEnd := runStart
+		for runEnd < len(p.subject) && p.subject[runEnd] == '`' {
+			runEnd++
+		}
+
+		if runEnd-runStart == len(ticks) {
+			content := strings.ReplaceAll(p.subject[afterOpen:runStart], "\n", " ")
+			if len(content) > 2 && content[0] == ' ' && content[len(content)-1] == ' ' &&
+				strings.Trim(content, " ") != "" {
+				content = content[1 : len(content)-1]
+			}
+
+			p.nodes = append(p.nodes, inline{kind: codeInline, literal: content})
+			p.pos = runEnd
+
+			return
+		}
+
+		search = runEnd
+	}
+
+	// No matching closing backticks, so they are literal text
+	p.addText(ticks)
+}
+
+func (p *inlineParser) parseLessThan() {
+	rest := p.subject[p.pos:]
+
+	if match := autolinkPattern.FindString(rest); match != "" {
+		p.pos += len(match)
+		p.addText(match[1 : len(match)-1])
+
+		return
+	}
+
+	if match := emailAutolinkPattern.FindString(rest); match != "" {
+		p.pos += len(match)
+		p.addText(match[1 : len(match)-1])
+
+		return
+	}
+
+	if match := htmlTagPattern.FindString(rest); match != "" {
+		p.html = append(p.html, HTML{Content: match, Block: false, source: p.source, start: p.pos})
+		p.pos += len(match)
+		p.nodes = append(p.nodes, inline{kind: htmlInline, literal: match})
+
+		return
+	}
+
+	p.pos++
+	p.addText("<")
+}
+
+func isUnicodePunctuation(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+func (p *inlineParser) handleDelimiter(char byte) {
+	start := p.pos
+	for p.pos < len(p.subject) && p.subject[p.pos] == char {
+		p.pos++
+	}
+
+	count := p.pos - start
+
+	before := '\n'
+	if start > 0 {
+		before, _ = utf8.DecodeLastRuneInString(p.subject[:start])
+	}
+
+	after := '\n'
+	if p.pos < len(p.subject) {
+		after, _ = utf8.DecodeRuneInString(p.subject[p.pos:])
+	}
+
+	afterIsWhitespace := unicode.IsSpace(after)
+	afterIsPunctuation := isUnicodePunctuation(after)
+	beforeIsWhitespace := unicode.IsSpace(before)
+	beforeIsPunctuation := isUnicodePunctuation(before)
+
+	leftFlanking := !afterIsWhitespace && (!afterIsPunctuation || beforeIsWhitespace || beforeIsPunctuation)
+	rightFlanking := !beforeIsWhitespace && (!beforeIsPunctuation || afterIsWhitespace || afterIsPunctuation)
+
+	canOpen, canClose := leftFlanking, rightFlanking
+	if char == '_' {
+		canOpen = leftFlanking && (!rightFlanking || beforeIsPunctuation)
+		canClose = rightFlanking && (!leftFlanking || afterIsPunctuation)
+	}
+
+	p.addText(p.subject[start:p.pos])
+
+	if !canOpen && !canClose {
+		return
+	}
+
+	entry := &delimiter{
+		char:       char,
+		numDelims:  count,
+		origDelims: count,
+		node:       len(p.nodes) - 1,
+		canOpen:    canOpen,
+		canClose:   canClose,
+		previous:   p.delimiters,
+	}
+
+	if entry.previous != nil {
+		entry.previous.next = entry
+	}
+
+	p.delimiters = entry
+}
+
+func (p *inlineParser) removeDelimiter(entry *delimiter) {
+	if entry.previous != nil {
+		entry.previous.next = entry.next
+	}
+
+	if entry.next != nil {
+		entry.next.previous = entry.previous
+	} else {
+		p.delimiters = entry.previous
+	}
+}
+
+//nolint:cyclop,funlen // the emphasis algorithm of the CommonMark spec
+func (p *inlineParser) processEmphasis(stackBottom *delimiter) {
+	openersBottom := make(map[openersBottomKey]*delimiter)
+
+	// Find the first closer above stackBottom
+	closer := p.delimiters
+	for closer != nil && closer.previous != stackBottom {
+		closer = closer.previous
+	}
+
+	for closer != nil {
+		if !closer.canClose {
+			closer = closer.next
+
+			continue
+		}
+
+		key := openersBottomKey{char: closer.char, canOpen: closer.canOpen, mod: closer.origDelims % 3}
+		bottom := openersBottom[key]
+
+		opener := closer.previous
+		openerFound := false
+
+		for opener != nil && opener != stackBottom && opener != bottom {
+			oddMatch := (closer.canOpen || opener.canClose) && closer.origDelims%3 != 0 &&
+				(opener.origDelims+closer.origDelims)%3 == 0
+			if opener.char == closer.char && opener.canOpen && !oddMatch {
+				openerFound = true
+
+				break
+			}
+
+			opener = opener.previous
+		}
+
+		oldCloser := closer
+
+		if openerFound {
+			useDelims := 1
+			if closer.numDelims >= 2 && opener.numDelims >= 2 {
+				useDelims = 2
+			}
+
+			opener.numDelims -= useDelims
+			closer.numDelims -= useDelims
+
+			// Emphasis markers are not part of the text
+			p.nodes[opener.node].literal = strings.Repeat(string(opener.char), opener.numDelims)
+			p.nodes[closer.node].literal = strings.Repeat(string(closer.char), closer.numDelims)
+
+			// Delimiters between opener and closer can no longer match
+			for entry := closer.previous; entry != nil && entry != opener; {
+				previous := entry.previous
+				p.removeDelimiter(entry)
+				entry = previous
+			}
+
+			if opener.numDelims == 0 {
+				p.removeDelimiter(opener)
+			}
+
+			if closer.numDelims == 0 {
+				next := closer.next
+				p.removeDelimiter(closer)
+				closer = next
+			}
+		} else {
+			closer = closer.next
+		}
+
+		if !openerFound {
+			openersBottom[key] = oldCloser.previous
+			if !oldCloser.canOpen {
+				p.removeDelimiter(oldCloser)
+			}
+		}
+	}
+
+	// Remove all delimiters above stackBottom
+	for p.delimiters != nil && p.delimiters != stackBottom {
+		p.removeDelimiter(p.delimiters)
+	}
+}
+
+func (p *inlineParser) addBracket(labelStart int, image bool) {
+	if p.brackets != nil {
+		p.brackets.bracketAfter = true
+	}
+
+	p.brackets = &bracket{
+		node:              len(p.nodes) - 1,
+		labelStart:        labelStart,
+		image:             image,
+		active:            true,
+		previous:          p.brackets,
+		previousDelimiter: p.delimiters,
+	}
+}
+
+func (p *inlineParser) removeBracket() {
+	p.brackets = p.brackets.previous
+}
+
+//nolint:cyclop,funlen // link syntax has several forms
+func (p *inlineParser) parseCloseBracket() {
+	closePos := p.pos
+	p.pos++
+
+	opener := p.brackets
+	if opener == nil {
+		p.addText("]")
+
+		return
+	}
+
+	if !opener.active {
+		p.addText("]")
+		p.removeBracket()
+
+		return
+	}
+
+	afterClose := p.pos
+	matched := false
+
+	var (
+		inlineLink Link
+		reference  Reference
+	)
+
+	// Inline link [text](destination "title")
+	if p.peek() == '(' {
+		p.pos++
+		p.spnl()
+
+		destStart := p.pos
+		if p.peek() == '<' {
+			destStart++
+		}
+
+		if destination, ok := p.parseLinkDestination(); ok {
+			destEnd := p.pos
+			if p.subject[destStart-1] == '<' {
+				destEnd--
+			}
+
+			beforeTitle := p.pos
+			p.spnl()
+
+			title := ""
+			if p.pos > beforeTitle {
+				if parsed, ok := p.parseLinkTitle(); ok {
+					title = parsed
+				}
+			}
+
+			p.spnl()
+
+			if p.peek() == ')' {
+				p.pos++
+				matched = true
+				inlineLink = Link{
+					Destination: destination,
+					Title:       title,
+					Image:       opener.image,
+					Position:    p.source.position(destStart),
+					End:         p.source.position(destEnd),
+				}
+			}
+		}
+
+		if !matched {
+			p.pos = afterClose
+		}
+	}
+
+	// Reference link [text][label], [label][] or [label]
+	if !matched {
+		labelStart := p.pos
+		length := p.parseLinkLabel()
+
+		var (
+			label    string
+			kind     ReferenceKind
+			position int
+			end      int
+		)
+
+		switch {
+		case length > 2:
+			label = p.subject[labelStart+1 : labelStart+length-1]
+			kind = Full
+			position = labelStart + 1
+			end = labelStart + length - 1
+		case !opener.bracketAfter:
+			label = p.subject[opener.labelStart:closePos]
+			kind = Shortcut
+			position = opener.labelStart
+			end = closePos
+
+			if length == 2 {
+				kind = Collapsed
+			}
+		}
+
+		if length == 0 {
+			p.pos = afterClose
+		}
+
+		normalised := NormaliseLabel(label)
+		if normalised != "" && len(label) <= maxLinkLabelLength {
+			reference = Reference{
+				Label:    normalised,
+				Kind:     kind,
+				Defined:  p.references[normalised],
+				Image:    opener.image,
+				Position: p.source.position(position),
+				End:      p.source.position(end),
+			}
+
+			if reference.Defined {
+				matched = true
+			} else if kind != Shortcut {
+				p.referenceLinks = append(p.referenceLinks, reference)
+			}
+		}
+	}
+
+	if !matched {
+		p.removeBracket()
+		p.pos = afterClose
+		p.addText("]")
+
+		return
+	}
+
+	if reference.Defined {
+		p.referenceLinks = append(p.referenceLinks, reference)
+	} else {
+		p.links = append(p.links, inlineLink)
+	}
+
+	openKind, closeKind := linkOpenInline, linkCloseInline
+	if opener.image {
+		openKind, closeKind = imageOpenInline, imageCloseInline
+	}
+
+	p.nodes[opener.node] = inline{kind: openKind, literal: ""}
+	p.nodes = append(p.nodes, inline{kind: closeKind, literal: ""})
+
+	p.processEmphasis(opener.previousDelimiter)
+	p.removeBracket()
+
+	// Links cannot contain other links
+	if !opener.image {
+		for entry := p.brackets; entry != nil; entry = entry.previous {
+			if !entry.image {
+				entry.active = false
+			}
+		}
+	}
+}
+
+func (p *inlineParser) parseLinkLabel() int {
+	match := linkLabelPattern.FindString(p.subject[p.pos:])
+	if match == "" {
+		return 0
+	}
+
+	p.pos += len(match)
+
+	return len(match)
+}
+
+//nolint:cyclop // destinations come in two forms
+func (p *inlineParser) parseLinkDestination() (string, bool) {
+	if p.peek() == '<' {
+		for i := p.pos + 1; i < len(p.subject); i++ {
+			switch p.subject[i] {
+			case '\\':
+				i++
+			case '\n', '<':
+				return "", false
+			case '>':
+				destination := unescapeString(p.subject[p.pos+1 : i])
+				p.pos = i + 1
+
+				return destination, true
+			}
+		}
+
+		return "", false
+	}
+
+	start := p.pos
+	depth := 0
+
+loop:
+	for p.pos < len(p.subject) {
+		switch c := p.subject[p.pos]; {
+		case c == '\\' && p.pos+1 < len(p.subject) && isASCIIPunctuation(p.subject[p.pos+1]):
+			p.pos += 2
+		case c == '(':
+			depth++
+			if depth > maxParenthesesDepth {
+				return "", false
+			}
+
+			p.pos++
+		case c == ')':
+			if depth < 1 {
+				break loop
+			}
+
+			depth--
+			p.pos++
+		case c <= ' ':
+			break loop
+		default:
+			p.pos++
+		}
+	}
+
+	if p.pos == start && p.peek() != ')' {
+		return "", false
+	}
+
+	if depth != 0 {
+		return "", false
+	}
+
+	return unescapeString(p.subject[start:p.pos]), true
+}
+
+func (p *inlineParser) parseLinkTitle() (string, bool) {
+	closing := byte(0)
+
+	switch p.peek() {
+	case '"':
+		closing = '"'
+	case '\'':
+		closing = '\''
+	case '(':
+		closing = ')'
+	default:
+		return "", false
+	}
+
+	for i := p.pos + 1; i < len(p.subject); i++ {
+		switch c := p.subject[i]; {
+		case c == '\\':
+			i++
+		case c == closing:
+			title := unescapeString(p.subject[p.pos+1 : i])
+			p.pos = i + 1
+
+			return title, true
+		case c == '(' && closing == ')':
+			return "", false
+		}
+	}
+
+	return "", false
+}
+
+// parseDefinition parses a link reference definition at offset and returns its length, 0 if there is none
+func parseDefinition(source text, offset int) (Definition, int) {
+	p := newInlineParser(source, nil)
+	p.pos = offset
+
+	labelLength := p.parseLinkLabel()
+	if labelLength == 0 || p.peek() != ':' {
+		return Definition{}, 0
+	}
+
+	label := NormaliseLabel(p.subject[offset+1 : offset+labelLength-1])
+	if label == "" {
+		return Definition{}, 0
+	}
+
+	p.pos++
+	p.spnl()
+
+	destStart := p.pos
+	if p.peek() == '<' {
+		destStart++
+	}
+
+	destination, ok := p.parseLinkDestination()
+	if !ok || (destination == "" && p.subject[destStart-1] != '<') {
+		return Definition{}, 0
+	}
+
+	destEnd := p.pos
+	if p.subject[destStart-1] == '<' {
+		destEnd--
+	}
+
+	beforeTitle := p.pos
+	p.spnl()
+
+	title := ""
+	if p.pos > beforeTitle {
+		title, ok = p.parseLinkTitle()
+	}
+
+	if !ok || !p.atLineEnd() {
+		// The title may be missing, but the destination must end the line then
+		title = ""
+		p.pos = beforeTitle
+
+		if !p.atLineEnd() {
+			return Definition{}, 0
+		}
+	}
+
+	if p.peek() == '\n' {
+		p.pos++
+	}
+
+	return Definition{
+		Label:       label,
+		Destination: destination,
+		Title:       title,
+		Duplicate:   false,
+		Position:    source.position(destStart),
+		End:         source.position(destEnd),
+	}, p.pos - offset
+}
+
+func (p *inlineParser) atLineEnd() bool {
+	p.skipSpaces()
+
+	return p.pos >= len(p.subject) || p.subject[p.pos] == '\n'
+}
+
+// plainText returns the text content of the parsed inlines, as a browser would render it
+func (p *inlineParser) plainText() string {
+	var builder strings.Builder
+
+	imageDepth := 0
+
+	for _, node := range p.nodes {
+		switch node.kind {
+		case imageOpenInline:
+			imageDepth++
+		case imageCloseInline:
+			imageDepth--
+		case textInline, codeInline, softBreakInline:
+			if imageDepth == 0 {
+				builder.WriteString(node.literal)
+			}
+		case htmlInline, linkOpenInline, linkCloseInline:
+		}
+	}
+
+	return strings.TrimSpace(builder.String())
+}
@@ -0,0 +1,387 @@
+package commonmark_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/anttiharju/relcheck/internal/markdown/commonmark"
+)
+
+type heading = commonmark.Heading
+
+type link = commonmark.Link
+
+type definition = commonmark.Definition
+
+type position = commonmark.Position
+
+// specExample is an example of the CommonMark spec (https://spec.commonmark.org/0.31.2/), described by
+// the headings, links and link reference definitions its HTML output has, rather than by the HTML itself
+type specExample struct {
+	name        string
+	markdown    string
+	headings    []heading
+	links       []link
+	definitions []definition
+}
+
+func h(level int, text string, line, column int) heading {
+	return heading{Level: level, Text: text, Position: position{Line: line, Column: column}}
+}
+
+// l is a link whose destination spans from column to endColumn, exclusive, as do those of img and def
+func l(destination, title string, line, column, endColumn int) link {
+	return link{
+		Destination: destination,
+		Title:       title,
+		Image:       false,
+		Position:    position{Line: line, Column: column},
+		End:         position{Line: line, Column: endColumn},
+	}
+}
+
+func img(destination, title string, line, column, endColumn int) link {
+	return link{
+		Destination: destination,
+		Title:       title,
+		Image:       true,
+		Position:    position{Line: line, Column: column},
+		End:         position{Line: line, Column: endColumn},
+	}
+}
+
+func def(label, destination, title string, line, column, endColumn int) definition {
+	return definition{
+		Label:       label,
+		Destination: destination,
+		Title:       title,
+		Duplicate:   false,
+		Position:    position{Line: line, Column: column},
+		End:         position{Line: line, Column: endColumn},
+	}
+}
+
+//nolint:gochecknoglobals
+var atxHeadings = []specExample{
+	{
+		name:     "levels one to six",
+		markdown: "# foo\n## foo\n### foo\n#### foo\n##### foo\n###### foo",
+		headings: []heading{
+			h(1, "foo", 1, 3), h(2, "foo", 2, 4), h(3, "foo", 3, 5),
+			h(4, "foo", 4, 6), h(5, "foo", 5, 7), h(6, "foo", 6, 8),
+		},
+	},
+	{name: "more than six", markdown: "####### foo"},
+	{name: "space required", markdown: "#5 bolt\n\n#hashtag"},
+	{name: "escaped", markdown: "\\## foo"},
+	{name: "inline content", markdown: "# foo *bar* \\*baz\\*", headings: []heading{h(1, "foo bar *baz*", 1, 3)}},
+	{
+		name:     "surrounding spaces",
+		markdown: "#                  foo                     ",
+		headings: []heading{h(1, "foo", 1, 20)},
+	},
+	{
+		name:     "indented up to three spaces",
+		markdown: " ### foo\n  ## foo\n   # foo",
+		headings: []heading{h(3, "foo", 1, 6), h(2, "foo", 2, 6), h(1, "foo", 3, 6)},
+	},
+	{name: "indented code", markdown: "    # foo"},
+	{name: "paragraph continuation", markdown: "foo\n    # bar"},
+	{
+		name:     "closing sequence",
+		markdown: "## foo ##\n  ###   bar    ###",
+		headings: []heading{h(2, "foo", 1, 4), h(3, "bar", 2, 9)},
+	},
+	{
+		name:     "closing sequence of any length",
+		markdown: "# foo ##################################\n##### foo ##",
+		headings: []heading{h(1, "foo", 1, 3), h(5, "foo", 2, 7)},
+	},
+	{name: "spaces after closing sequence", markdown: "### foo ###     ", headings: []heading{h(3, "foo", 1, 5)}},
+	{name: "not a closing sequence", markdown: "### foo ### b", headings: []heading{h(3, "foo ### b", 1, 5)}},
+	{name: "closing sequence needs a space", markdown: "# foo#", headings: []heading{h(1, "foo#", 1, 3)}},
+	{
+		name:     "escaped closing sequence",
+		markdown: "### foo \\###\n## foo #\\##\n# foo \\#",
+		headings: []heading{h(3, "foo ###", 1, 5), h(2, "foo ###", 2, 4), h(1, "foo #", 3, 3)},
+	},
+	{name: "between thematic breaks", markdown: "****\n## foo\n****", headings: []heading{h(2, "foo", 2, 4)}},
+	{name: "interrupts a paragraph", markdown: "Foo bar\n# baz\nBar foo", headings: []heading{h(1, "baz", 2, 3)}},
+	{
+		name:     "empty",
+		markdown: "## \n#\n### ###",
+		headings: []heading{h(2, "", 1, 4), h(1, "", 2, 2), h(3, "", 3, 5)},
+	},
+	{name: "within a block quote", markdown: "> # foo", headings: []heading{h(1, "foo", 1, 5)}},
+	{name: "within a list item", markdown: "- ## foo", headings: []heading{h(2, "foo", 1, 6)}},
+}
+
+//nolint:gochecknoglobals
+var setextHeadings = []specExample{
+	{
+		name:     "both levels",
+		markdown: "Foo *bar*\n=========\n\nFoo *bar*\n---------",
+		headings: []heading{h(1, "Foo bar", 1, 1), h(2, "Foo bar", 4, 1)},
+	},
+	{name: "multiple lines", markdown: "Foo *bar\nbaz*\n====", headings: []heading{h(1, "Foo bar\nbaz", 1, 1)}},
+	{
+		name:     "underline of any length",
+		markdown: "Foo\n-------------------------\n\nFoo\n=",
+		headings: []heading{h(2, "Foo", 1, 1), h(1, "Foo", 4, 1)},
+	},
+	{
+		name:     "indented content",
+		markdown: "   Foo\n---\n\n  Foo\n-----\n\n  Foo\n  ===",
+		headings: []heading{h(2, "Foo", 1, 4), h(2, "Foo", 4, 3), h(1, "Foo", 7, 3)},
+	},
+	{name: "indented code", markdown: "    Foo\n    ---\n\n    Foo\n---"},
+	{name: "indented underline", markdown: "Foo\n   ----      ", headings: []heading{h(2, "Foo", 1, 1)}},
+	{name: "underline indented too far", markdown: "Foo\n    ---"},
+	{name: "underline with spaces", markdown: "Foo\n= =\n\nFoo\n--- -"},
+	{name: "trailing spaces", markdown: "Foo  \n-----", headings: []heading{h(2, "Foo", 1, 1)}},
+	{name: "trailing backslash", markdown: "Foo\\\n----", headings: []heading{h(2, "Foo\\", 1, 1)}},
+	{
+		name:     "underline wins over inline structure",
+		markdown: "`Foo\n----\n`\n\n<a title=\"a lot\n---\nof dashes\"/>",
+		headings: []heading{h(2, "`Foo", 1, 1), h(2, "<a title=\"a lot", 5, 1)},
+	},
+	{name: "lazy continuation of a block quote", markdown: "> Foo\n---"},
+	{name: "lazy continuation of a list item", markdown: "- Foo\n---"},
+	{name: "paragraph of several lines", markdown: "Foo\nBar\n---", headings: []heading{h(2, "Foo\nBar", 1, 1)}},
+	{
+		name:     "between thematic breaks",
+		markdown: "---\nFoo\n---\nBar\n---\nBaz",
+		headings: []heading{h(2, "Foo", 2, 1), h(2, "Bar", 4, 1)},
+	},
+	{name: "empty", markdown: "\n===="},
+	{name: "thematic breaks", markdown: "---\n---"},
+	{name: "escaped block quote", markdown: "\\> foo\n------", headings: []heading{h(2, "> foo", 1, 1)}},
+	{name: "after a paragraph", markdown: "Foo\n\nbar\n---\nbaz", headings: []heading{h(2, "bar", 3, 1)}},
+}
+
+//nolint:gochecknoglobals
+var linkReferenceDefinitions = []specExample{
+	{
+		name:        "simple",
+		markdown:    "[foo]: /url \"title\"\n\n[foo]",
+		definitions: []definition{def("foo", "/url", "title", 1, 8, 12)},
+	},
+	{
+		name:        "across lines",
+		markdown:    "   [foo]: \n      /url  \n           'the title'  \n\n[foo]",
+		definitions: []definition{def("foo", "/url", "the title", 2, 7, 11)},
+	},
+	{
+		name:        "escapes in the label",
+		markdown:    "[Foo*bar\\]]:my_(url) 'title (with parens)'\n\n[Foo*bar\\]]",
+		definitions: []definition{def("foo*bar\\]", "my_(url)", "title (with parens)", 1, 13, 21)},
+	},
+	{
+		name:        "pointy brackets",
+		markdown:    "[Foo bar]:\n<my url>\n'title'\n\n[Foo bar]",
+		definitions: []definition{def("foo bar", "my url", "title", 2, 2, 8)},
+	},
+	{name: "missing destination", markdown: "[foo]:\n\n[foo]"},
+	{name: "empty destination", markdown: "[foo]: <>\n\n[foo]", definitions: []definition{def("foo", "", "", 1, 9, 9)}},
+	{name: "no space before title", markdown: "[foo]: <bar>(baz)\n\n[foo]"},
+	{
+		name:        "backslash escapes",
+		markdown:    "[foo]: /url\\bar\\*baz \"foo\\\"bar\\baz\"\n\n[foo]",
+		definitions: []definition{def("foo", "/url\\bar*baz", "foo\"bar\\baz", 1, 8, 21)},
+	},
+	{
+		name:        "used before definition",
+		markdown:    "[foo]\n\n[foo]: url",
+		definitions: []definition{def("foo", "url", "", 3, 8, 11)},
+	},
+	{
+		name:     "first definition wins",
+		markdown: "[foo]\n\n[foo]: first\n[foo]: second",
+		definitions: []definition{
+			def("foo", "first", "", 3, 8, 13),
+			{
+				Label:       "foo",
+				Destination: "second",
+				Title:       "",
+				Duplicate:   true,
+				Position:    position{Line: 4, Column: 8},
+				End:         position{Line: 4, Column: 14},
+			},
+		},
+	},
+	{name: "case-insensitive", markdown: "[FOO]: /url\n\n[Foo]", definitions: []definition{def("foo", "/url", "", 1, 8, 12)}},
+	{
+		name:        "unicode case folding",
+		markdown:    "[ΑΓΩ]: /φου\n\n[αγω]",
+		definitions: []definition{def("αγω", "/φου", "", 1, 11, 18)}, // Columns count bytes
+	},
+	{name: "unused", markdown: "[foo]: /url", definitions: []definition{def("foo", "/url", "", 1, 8, 12)}},
+	{name: "within fenced code", markdown: "```\n[foo]: /url\n```"},
+	{name: "cannot interrupt a paragraph", markdown: "Foo\n[bar]: /baz\n\n[bar]"},
+	{
+		name:        "after a heading",
+		markdown:    "# [Foo]\n[foo]: /url\n> bar",
+		headings:    []heading{h(1, "Foo", 1, 3)},
+		definitions: []definition{def("foo", "/url", "", 2, 8, 12)},
+	},
+	{
+		name:     "several in a row",
+		markdown: "[foo]: /foo-url \"foo\"\n[bar]: /bar-url\n  \"bar\"\n[baz]: /baz-url\n\n[foo],\n[bar],\n[baz]",
+		definitions: []definition{
+			def("foo", "/foo-url", "foo", 1, 8, 16),
+			def("bar", "/bar-url", "bar", 2, 8, 16),
+			def("baz", "/baz-url", "", 4, 8, 16),
+		},
+	},
+	{
+		name:        "within a block quote",
+		markdown:    "[foo]\n\n> [foo]: /url",
+		definitions: []definition{def("foo", "/url", "", 3, 10, 14)},
+	},
+}
+
+//nolint:gochecknoglobals
+var links = []specExample{
+	{name: "with title", markdown: "[link](/uri \"title\")", links: []link{l("/uri", "title", 1, 8, 12)}},
+	{name: "without title", markdown: "[link](/uri)", links: []link{l("/uri", "", 1, 8, 12)}},
+	{name: "empty destination", markdown: "[link]()", links: []link{l("", "", 1, 8, 8)}},
+	{name: "empty pointy destination", markdown: "[link](<>)", links: []link{l("", "", 1, 9, 9)}},
+	{name: "space in destination", markdown: "[link](/my uri)"},
+	{name: "space in pointy destination", markdown: "[link](</my uri>)", links: []link{l("/my uri", "", 1, 9, 16)}},
+	{name: "line break in destination", markdown: "[link](foo\nbar)"},
+	{name: "parenthesis in pointy destination", markdown: "[a](<b)c>)", links: []link{l("b)c", "", 1, 6, 9)}},
+	{name: "unclosed pointy destination", markdown: "[link](<foo\\>)"},
+	{name: "escaped parentheses", markdown: "[link](\\(foo\\))", links: []link{l("(foo)", "", 1, 8, 15)}},
+	{name: "balanced parentheses", markdown: "[link](foo(and(bar)))", links: []link{l("foo(and(bar))", "", 1, 8, 21)}},
+	{name: "unbalanced parentheses", markdown: "[link](foo(and(bar))"},
+	{
+		name:     "escaped unbalanced parentheses",
+		markdown: "[link](foo\\(and\\(bar\\))",
+		links:    []link{l("foo(and(bar)", "", 1, 8, 23)},
+	},
+	{
+		name:     "pointy unbalanced parentheses",
+		markdown: "[link](<foo(and(bar)>)",
+		links:    []link{l("foo(and(bar)", "", 1, 9, 21)},
+	},
+	{name: "escapes", markdown: "[link](foo\\)\\:)", links: []link{l("foo):", "", 1, 8, 15)}},
+	{
+		name:     "fragments and queries",
+		markdown: "[link](#fragment)\n\n[link](http://example.com#fragment)\n\n[link](http://example.com?foo=3#frag)",
+		links: []link{
+			l("#fragment", "", 1, 8, 17),
+			l("http://example.com#fragment", "", 3, 8, 35),
+			l("http://example.com?foo=3#frag", "", 5, 8, 37),
+		},
+	},
+	{name: "backslash before a letter", markdown: "[link](foo\\bar)", links: []link{l("foo\\bar", "", 1, 8, 15)}},
+	{name: "entities", markdown: "[link](foo%20b&auml;)", links: []link{l("foo%20bä", "", 1, 8, 21)}},
+	{name: "title-like destination", markdown: "[link](\"title\")", links: []link{l("\"title\"", "", 1, 8, 15)}},
+	{
+		name:     "title delimiters",
+		markdown: "[link](/url \"title\")\n[link](/url 'title')\n[link](/url (title))",
+		links:    []link{l("/url", "title", 1, 8, 12), l("/url", "title", 2, 8, 12), l("/url", "title", 3, 8, 12)},
+	},
+	{
+		name:     "escapes and entities in title",
+		markdown: "[link](/url \"title \\\"&quot;\")",
+		links:    []link{l("/url", "title \"\"", 1, 8, 12)},
+	},
+	{
+		name:     "non-breaking space is not whitespace",
+		markdown: "[link](/url\u00a0\"title\")",
+		links:    []link{l("/url\u00a0\"title\"", "", 1, 8, 21)},
+	},
+	{name: "unescaped quotes in title", markdown: "[link](/url \"title \"and\" title\")"},
+	{name: "whitespace around", markdown: "[link](   /uri\n  \"title\"  )", links: []link{l("/uri", "title", 1, 11, 15)}},
+	{name: "space before destination", markdown: "[link] (/uri)"},
+	{name: "nested brackets", markdown: "[link [foo [bar]]](/uri)", links: []link{l("/uri", "", 1, 20, 24)}},
+	{name: "unbalanced brackets", markdown: "[link] bar](/uri)"},
+	{name: "inner link", markdown: "[link [bar](/uri)", links: []link{l("/uri", "", 1, 13, 17)}},
+	{name: "escaped bracket", markdown: "[link \\[bar](/uri)", links: []link{l("/uri", "", 1, 14, 18)}},
+	{
+		name:     "image within link",
+		markdown: "[![moon](moon.jpg)](/uri)",
+		links:    []link{img("moon.jpg", "", 1, 10, 18), l("/uri", "", 1, 21, 25)},
+	},
+	{name: "links cannot contain links", markdown: "[foo [bar](/uri)](/uri)", links: []link{l("/uri", "", 1, 12, 16)}},
+	{
+		name:     "nested emphasis and links",
+		markdown: "[foo *[bar [baz](/uri)](/uri)*](/uri)",
+		links:    []link{l("/uri", "", 1, 18, 22)},
+	},
+	{
+		name:     "images can contain links",
+		markdown: "![[[foo](uri1)](uri2)](uri3)",
+		links:    []link{l("uri1", "", 1, 10, 14), img("uri3", "", 1, 24, 28)},
+	},
+	{name: "brackets bind tighter than emphasis", markdown: "*[foo*](/uri)", links: []link{l("/uri", "", 1, 9, 13)}},
+	{name: "emphasis in destination", markdown: "[foo *bar](baz*)", links: []link{l("baz*", "", 1, 12, 16)}},
+	{name: "raw HTML binds tighter", markdown: "[foo <bar attr=\"](baz)\">"},
+	{name: "code spans bind tighter", markdown: "[foo`](/uri)`"},
+	{name: "autolinks bind tighter", markdown: "[foo<http://example.com/?search=](uri)>"},
+	{name: "within a code span", markdown: "`[foo](/uri)`"},
+	{name: "within fenced code", markdown: "```\n[foo](/uri)\n```"},
+	{name: "within indented code", markdown: "    [foo](/uri)"},
+	{name: "within an HTML block", markdown: "<div>\n[foo](/uri)\n</div>"},
+	{name: "within an HTML comment", markdown: "<!-- [foo](/uri) -->"},
+	{
+		name:     "within container blocks",
+		markdown: "> [a](b)\n- [c](d)\n  > 1. [e](f)",
+		links:    []link{l("b", "", 1, 7, 8), l("d", "", 2, 7, 8), l("f", "", 3, 12, 13)},
+	},
+	{name: "text across lines", markdown: "[a\nb](c)", links: []link{l("c", "", 2, 4, 5)}},
+	{name: "destination on the next line", markdown: "[a](\nc)", links: []link{l("c", "", 2, 1, 2)}},
+}
+
+//nolint:gochecknoglobals
+var images = []specExample{
+	{name: "with title", markdown: "![foo](/url \"title\")", links: []link{img("/url", "title", 1, 8, 12)}},
+	{name: "without title", markdown: "![foo](train.jpg)", links: []link{img("train.jpg", "", 1, 8, 17)}},
+	{
+		name:     "spaces around title",
+		markdown: "My ![foo bar](/path/to/train.jpg  \"title\"   )",
+		links:    []link{img("/path/to/train.jpg", "title", 1, 15, 33)},
+	},
+	{name: "pointy destination", markdown: "![foo](<url>)", links: []link{img("url", "", 1, 9, 12)}},
+	{name: "empty description", markdown: "![](/url)", links: []link{img("/url", "", 1, 5, 9)}},
+	{
+		name:        "reference",
+		markdown:    "![foo *bar*]\n\n[foo *bar*]: train.jpg \"train & tracks\"",
+		definitions: []definition{def("foo *bar*", "train.jpg", "train & tracks", 3, 14, 23)},
+	},
+}
+
+func TestSpecExamples(t *testing.T) {
+	t.Parallel()
+
+	sections := map[string][]specExample{
+		"ATX headings":               atxHeadings,
+		"Setext headings":            setextHeadings,
+		"Link reference definitions": linkReferenceDefinitions,
+		"Links":                      links,
+		"Images":                     images,
+	}
+
+	for section, examples := range sections {
+		for _, example := range examples {
+			t.Run(section+"/"+example.name, func(t *testing.T) {
+				t.Parallel()
+
+				document := commonmark.Parse(example.markdown)
+
+				if !slices.Equal(document.Headings, example.headings) {
+					t.Errorf("headings of %q:\n got %+v\nwant %+v", example.markdown, document.Headings, example.headings)
+				}
+
+				if !slices.Equal(document.Links, example.links) {
+					t.Errorf("links of %q:\n got %+v\nwant %+v", example.markdown, document.Links, example.links)
+				}
+
+				if !slices.Equal(document.Definitions, example.definitions) {
+					t.Errorf("definitions of %q:\n got %+v\nwant %+v", example.markdown, document.Definitions, example.definitions)
+				}
+			})
+		}
+	}
+}
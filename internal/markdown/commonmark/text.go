@@ -0,0 +1,63 @@
+package commonmark
+
+import (
+	"sort"
+	"strings"
+)
+
+// segment is a part of a source line that belongs to a block
+type segment struct {
+	text   string
+	line   int // 1-based line number
+	offset int // Byte offset of text within the source line
+}
+
+// text joins the segments of a block with newlines while remembering where each byte came from
+type text struct {
+	content string
+	starts  []int // Offset of each segment within content
+	lines   []segment
+}
+
+func newText(lines []segment) text {
+	var builder strings.Builder
+
+	starts := make([]int, len(lines))
+
+	for i, line := range lines {
+		if i > 0 {
+			builder.WriteByte('\n')
+		}
+
+		starts[i] = builder.Len()
+		builder.WriteString(line.text)
+	}
+
+	return text{
+		content: builder.String(),
+		starts:  starts,
+		lines:   lines,
+	}
+}
+
+func (t text) position(offset int) Position {
+	if len(t.lines) == 0 {
+		return Position{Line: 0, Column: 0}
+	}
+
+	index := max(t.lineIndex(offset), 0)
+
+	line := t.lines[index]
+
+	return Position{
+		Line:   line.line,
+		Column: line.offset + offset - t.starts[index] + 1, // +1 because columns start at 1
+	}
+}
+
+// lineIndex returns the index of the segment that contains offset
+func (t text) lineIndex(offset int) int {
+	return sort.Search(len(t.starts), func(i int) bool {
+		return t.starts[i] > offset
+	}) - 1
+}
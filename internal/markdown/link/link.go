@@ -1,32 +1,70 @@
 package link
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
+
+	"github.com/anttiharju/relcheck/internal/rule"
 )
 
 type Link struct {
 	URL         string
 	Line        int
 	Column      int
-	Path        string // Resolved path
+	EndColumn   int    // Just after the link in the source, which escapes and entities can make longer than the URL
+	Path        string // Path part of the URL
 	Anchor      string // Anchor part if present
+	Target      string // Resolved target file, empty until resolved
+	Fix         string // URL that would fix a broken link, empty if none is known
+	Severity    rule.Severity
 	IsValid     bool
 	LineContent string
 }
 
+// Matches URI schemes such as http:, mailto: and data:
+var schemePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+
+func IsRelative(target string) bool {
+	if target == "" {
+		return false
+	}
+
+	if schemePattern.MatchString(target) {
+		return false
+	}
+
+	// Protocol-relative links (//host/path) point to another site
+	return !strings.HasPrefix(target, "//")
+}
+
+// IsRootRelative reports whether the target is resolved against the repository root, like /docs/setup.md
+func IsRootRelative(target string) bool {
+	return strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//")
+}
+
 func SplitLinkAndAnchor(link string) (string, string) {
 	parts := strings.SplitN(link, "#", 2)
 	if len(parts) == 2 {
-		pathPart := parts[0]
-		anchorPart := parts[1]
+		// An empty path part means the anchor refers to the current file
+		return parts[0], parts[1]
+	}
 
-		// If the path part is empty, treat the link as relative to current directory
-		if pathPart == "" {
-			return ".", anchorPart
-		}
+	return link, ""
+}
+
+// EncodeName percent-encodes the characters of a file name that would end a link or change its meaning,
+// like %20 for spaces, %23 for # that would start an anchor and %2B for + that is decoded as a space
+func EncodeName(name string) string {
+	var builder strings.Builder
 
-		return pathPart, anchorPart
+	for _, r := range name {
+		if strings.ContainsRune(" \t#%+?()<>", r) {
+			fmt.Fprintf(&builder, "%%%02X", r)
+		} else {
+			builder.WriteRune(r)
+		}
 	}
 
-	return link, ""
+	return builder.String()
 }
@@ -0,0 +1,81 @@
+package scan
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+//nolint:gochecknoglobals
+var scanCache = &cache{mu: sync.Mutex{}, entries: make(map[string]*cacheEntry)}
+
+// cache scans every file once, even when several goroutines ask for it at the same time
+type cache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	once   sync.Once
+	result Result
+	err    error
+}
+
+func (c *cache) get(path string, scan func() (Result, error)) (Result, error) {
+	c.mu.Lock()
+
+	entry, ok := c.entries[path]
+	if !ok {
+		entry = &cacheEntry{once: sync.Once{}, result: Result{}, err: nil}
+		c.entries[path] = entry
+	}
+
+	c.mu.Unlock()
+
+	// Others asking for the same file wait here until the first scan is done
+	entry.once.Do(func() {
+		entry.result, entry.err = scan()
+	})
+
+	return entry.result, entry.err
+}
+
+// Remember sets the result of a file whose content is not saved yet, such as an editor buffer
+func Remember(path string, result Result) {
+	scanCache.set(path, result)
+}
+
+func (c *cache) set(path string, result Result) {
+	entry := &cacheEntry{once: sync.Once{}, result: result, err: nil}
+	entry.once.Do(func() {})
+
+	c.mu.Lock()
+	c.entries[path] = entry
+	c.mu.Unlock()
+}
+
+// Forget drops the results of the changed files, or of everything below changed directories,
+// so that they are scanned again. Paths are absolute.
+func Forget(changed []string) {
+	scanCache.forget(changed)
+}
+
+func (c *cache) forget(changed []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path := range c.entries {
+		absolutePath, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+
+		for _, changedPath := range changed {
+			if absolutePath == changedPath || strings.HasPrefix(absolutePath, changedPath+string(filepath.Separator)) {
+				delete(c.entries, path)
+
+				break
+			}
+		}
+	}
+}
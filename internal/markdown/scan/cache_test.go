@@ -0,0 +1,65 @@
+package scan
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCacheScansOnce(t *testing.T) {
+	t.Parallel()
+
+	c := &cache{mu: sync.Mutex{}, entries: make(map[string]*cacheEntry)}
+	files := []string{"a.md", "b.md", "c.md"}
+
+	scans := make(map[string]*atomic.Int32, len(files))
+	for _, file := range files {
+		scans[file] = &atomic.Int32{}
+	}
+
+	// Each file gets a result of its own, so that a getter handed the result of another file is caught
+	getAll := func() {
+		var wg sync.WaitGroup
+
+		for range 32 {
+			for i, file := range files {
+				wg.Go(func() {
+					result, err := c.get(file, func() (Result, error) {
+						scans[file].Add(1)
+
+						return Result{LineCount: i + 1}, nil
+					})
+					if err != nil || result.LineCount != i+1 {
+						t.Errorf("get(%s) = %d lines, %v, want %d lines", file, result.LineCount, err, i+1)
+					}
+				})
+			}
+		}
+
+		wg.Wait()
+	}
+
+	getAll()
+
+	for _, file := range files {
+		if got := scans[file].Load(); got != 1 {
+			t.Errorf("%s was scanned %d times, want once", file, got)
+		}
+	}
+
+	// Forgetting a file scans it once more, and only it
+	absolutePath, err := filepath.Abs("a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.forget([]string{absolutePath})
+	getAll()
+
+	for file, want := range map[string]int32{"a.md": 2, "b.md": 1, "c.md": 1} {
+		if got := scans[file].Load(); got != want {
+			t.Errorf("%s was scanned %d times after forgetting a.md, want %d", file, got, want)
+		}
+	}
+}
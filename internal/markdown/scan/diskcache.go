@@ -0,0 +1,143 @@
+package scan
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/anttiharju/relcheck/internal/markdown/anchor"
+)
+
+// Bump when Result changes shape or meaning, so that older entries are not read
+const diskCacheVersion = 4
+
+// Entries and whole binaries' directories unused for this long are removed when the cache is opened
+const diskCacheMaxAge = 7 * 24 * time.Hour
+
+// Names of the directories of binaries and of their entries, so that pruning leaves other files alone
+var (
+	stampPattern = regexp.MustCompile(`^[0-9a-f]{16}$`)
+	entryPattern = regexp.MustCompile(`^(?:[0-9a-f]{64}|entry-[0-9]+)$`)
+)
+
+// DiskCache keeps scan results between runs, keyed by the content of the file.
+// It is best effort: entries that cannot be read or written are scanned again.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache uses a directory of dir that is specific to the running binary and the anchor style,
+// as both decide what a scan results in
+func NewDiskCache(dir string, style anchor.Style) (*DiskCache, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the running binary: %w", err)
+	}
+
+	info, err := os.Stat(executable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect the running binary: %w", err)
+	}
+
+	stamp := sha256.Sum256(fmt.Appendf(nil, "%d\x00%s\x00%d\x00%d\x00%s",
+		diskCacheVersion, executable, info.Size(), info.ModTime().UnixNano(), style))
+	stampDir := filepath.Join(dir, hex.EncodeToString(stamp[:8]))
+
+	if err := os.MkdirAll(stampDir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	prune(dir, stampDir, time.Now())
+
+	return &DiskCache{dir: stampDir}, nil
+}
+
+// prune removes what has not been used for diskCacheMaxAge, such as the entries of earlier binaries.
+// It is best effort, like the rest of the cache.
+func prune(dir, stampDir string, now time.Time) {
+	// The directory in use is kept, however old its entries are
+	_ = os.Chtimes(stampDir, now, now)
+
+	removeUnused(dir, stampPattern, now)
+	removeUnused(stampDir, entryPattern, now)
+}
+
+func removeUnused(dir string, names *regexp.Regexp, now time.Time) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if !names.MatchString(entry.Name()) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < diskCacheMaxAge {
+			continue
+		}
+
+		_ = os.RemoveAll(filepath.Join(dir, entry.Name()))
+	}
+}
+
+func (d *DiskCache) path(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+func (d *DiskCache) load(content []byte) (Result, bool) {
+	if d == nil {
+		return Result{}, false
+	}
+
+	path := d.path(content)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Result{}, false
+	}
+
+	// Entries in use are kept by prune
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	var result Result
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&result); err != nil {
+		return Result{}, false
+	}
+
+	return result, true
+}
+
+func (d *DiskCache) store(content []byte, result Result) {
+	if d == nil {
+		return
+	}
+
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(result); err != nil {
+		return
+	}
+
+	// Written aside and renamed, so that concurrent runs never read half an entry
+	temp, err := os.CreateTemp(d.dir, "entry-*")
+	if err != nil {
+		return
+	}
+
+	_, writeErr := temp.Write(data.Bytes())
+	closeErr := temp.Close()
+
+	if writeErr != nil || closeErr != nil || os.Rename(temp.Name(), d.path(content)) != nil {
+		_ = os.Remove(temp.Name())
+	}
+}
@@ -0,0 +1,68 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Now()
+	old := now.Add(-diskCacheMaxAge - time.Hour)
+	recent := now.Add(-time.Hour)
+	usedEntry := "00000000000000aa/" + strings.Repeat("ab", 32)
+	unusedEntry := "00000000000000aa/" + strings.Repeat("cd", 32)
+
+	// Directories come before their files
+	paths := []struct {
+		path    string
+		dir     bool
+		modTime time.Time
+		kept    bool
+	}{
+		{path: "00000000000000aa", dir: true, modTime: old, kept: true}, // In use
+		{path: usedEntry, dir: false, modTime: recent, kept: true},
+		{path: unusedEntry, dir: false, modTime: old, kept: false},
+		{path: "00000000000000aa/entry-123", dir: false, modTime: old, kept: false}, // Of an interrupted store
+		{path: "00000000000000aa/notes.txt", dir: false, modTime: old, kept: true},  // Not the cache's
+		{path: "00000000000000bb", dir: true, modTime: old, kept: false},            // Of an earlier binary
+		{path: "00000000000000cc", dir: true, modTime: recent, kept: true},          // Of another anchor style
+		{path: "projects", dir: true, modTime: old, kept: true},                     // Not the cache's
+	}
+
+	for _, p := range paths {
+		full := filepath.Join(dir, p.path)
+
+		var err error
+		if p.dir {
+			err = os.Mkdir(full, 0o700)
+		} else {
+			err = os.WriteFile(full, nil, 0o600)
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Times are set afterwards, as creating files updates the time of their directory
+	for _, p := range paths {
+		if err := os.Chtimes(filepath.Join(dir, p.path), p.modTime, p.modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prune(dir, filepath.Join(dir, "00000000000000aa"), now)
+
+	for _, p := range paths {
+		_, err := os.Stat(filepath.Join(dir, p.path))
+		if kept := err == nil; kept != p.kept {
+			t.Errorf("%s kept = %v, want %v", p.path, kept, p.kept)
+		}
+	}
+}
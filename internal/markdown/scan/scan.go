@@ -1,40 +1,41 @@
 package scan
 
 import (
-	"bufio"
+	"cmp"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/anttiharju/relcheck/internal/fileutils"
 	"github.com/anttiharju/relcheck/internal/markdown/anchor"
+	"github.com/anttiharju/relcheck/internal/markdown/commonmark"
 	"github.com/anttiharju/relcheck/internal/markdown/link"
 )
 
 type Result struct {
-	Links     []link.Link
-	Anchors   []string
-	LineCount int
+	Links               []link.Link
+	Anchors             []string
+	AnchorLines         []int // Line of each anchor
+	LineCount           int
+	UndefinedReferences []link.Link // References such as [text][id] without a matching definition
+	UnusedDefinitions   []link.Link // Definitions such as [id]: ./path.md that nothing refers to
+	Suppressions        []Suppression
+	UnknownRules        []link.Link // Rule names of suppression comments that match no rule
 }
 
-//nolint:gochecknoglobals
-var scanCache = make(map[string]Result)
+var attributeListPattern = regexp.MustCompile(`[ \t]+\{:?([^{}]*)\}$`)
 
-var (
-	relativeLinkPattern = regexp.MustCompile(`\]\(\.[^)"']*(?:"[^"]*"|'[^']*')?\)`)
-	headingPattern      = regexp.MustCompile(`^#{1,6} `)
-	headingTextPattern  = regexp.MustCompile(`^#+[ \t]+`)
-	headingAltPattern   = regexp.MustCompile(`^(-+|=+)\s*$`)
-	markdownLinkPattern = regexp.MustCompile(`\[(.*?)\]\([^\)]*\)`)
-)
-
-func File(filepath string) (Result, error) {
-	// Check cache first
-	if result, ok := scanCache[filepath]; ok {
-		return result, nil
-	}
+// File scans a file once per run, and once per content if a disk cache is given
+func File(filepath string, slugger anchor.Slugger, disk *DiskCache) (Result, error) {
+	return scanCache.get(filepath, func() (Result, error) {
+		return scanPath(filepath, slugger, disk)
+	})
+}
 
+func scanPath(filepath string, slugger anchor.Slugger, disk *DiskCache) (Result, error) {
 	// Check if path is a directory
 	isDir, err := fileutils.IsDirectory(filepath)
 	if err != nil {
@@ -53,204 +54,211 @@ func File(filepath string) (Result, error) {
 	defer file.Close()
 
 	// Scan the file
-	result, err := scanFile(file)
+	return scanFile(file, slugger, disk)
+}
+
+func scanFile(file *os.File, slugger anchor.Slugger, disk *DiskCache) (Result, error) {
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return Result{}, fmt.Errorf("error scanning file: %w", err)
+	}
+
+	if result, ok := disk.load(content); ok {
+		return result, nil
+	}
+
+	result, err := Content(content, slugger)
 	if err != nil {
 		return Result{}, err
 	}
 
-	// Cache the result
-	scanCache[filepath] = result
+	disk.store(content, result)
 
 	return result, nil
 }
 
-func isHTMLCommentLine(line string, inHTMLComment bool) (bool, bool) {
-	trimmed := strings.TrimSpace(line)
-
-	// Entering a multi-line comment
-	if strings.HasPrefix(trimmed, "<!--") && !strings.Contains(trimmed, "-->") {
-		return true, true
-	}
-	// Inside a multi-line comment
-	if inHTMLComment {
-		if strings.Contains(trimmed, "-->") {
-			return true, false
-		}
+// Content scans Markdown that may not be saved to a file, such as an editor buffer
+func Content(content []byte, slugger anchor.Slugger) (Result, error) {
+	document := commonmark.Parse(string(content))
 
-		return true, true
-	}
-	// Single-line comment
-	if strings.HasPrefix(trimmed, "<!--") && strings.Contains(trimmed, "-->") {
-		return true, false
-	}
-	// Not a comment
-	return false, inHTMLComment
-}
+	suppressions, unknownRules := extractSuppressions(document)
 
-//nolint:funlen // the function is pretty simple even if it is long
-func scanFile(file *os.File) (Result, error) {
-	links := []link.Link{}
-	anchors := []string{}
+	anchors, anchorLines := extractAnchors(document, slugger)
 
-	scanner := bufio.NewScanner(file)
-	inCodeBlock := false
-	inHTMLComment := false
-	lineNumber := 0
-	anchorCount := make(map[string]int)
+	return Result{
+		Links:               extractLinks(document),
+		Anchors:             anchors,
+		AnchorLines:         anchorLines,
+		LineCount:           len(document.Lines),
+		UndefinedReferences: extractUndefinedReferences(document),
+		UnusedDefinitions:   extractUnusedDefinitions(document),
+		Suppressions:        suppressions,
+		UnknownRules:        unknownRules,
+	}, nil
+}
 
-	var previousLine string // Store the previous line for altHeading detection
+func newLink(document *commonmark.Document, url string, position, end commonmark.Position) link.Link {
+	path, anchorText := link.SplitLinkAndAnchor(url)
+	lineContent := document.Lines[position.Line-1]
 
-	for scanner.Scan() {
-		lineNumber++
-		line := scanner.Text()
+	// Labels may continue on the next lines, the span then ends with the first one
+	endColumn := end.Column
+	if end.Line != position.Line {
+		endColumn = len(lineContent) + 1
+	}
 
-		skip, newInHTMLComment := isHTMLCommentLine(line, inHTMLComment)
-		inHTMLComment = newInHTMLComment
+	return link.Link{
+		URL:         url,
+		Line:        position.Line,
+		Column:      position.Column,
+		EndColumn:   endColumn,
+		Path:        path,
+		Anchor:      anchorText,
+		LineContent: lineContent,
+	}
+}
 
-		if skip {
-			previousLine = line
+func extractLinks(document *commonmark.Document) []link.Link {
+	links := []link.Link{}
 
-			continue
+	for _, inlineLink := range document.Links {
+		if link.IsRelative(inlineLink.Destination) {
+			links = append(links, newLink(document, inlineLink.Destination, inlineLink.Position, inlineLink.End))
 		}
+	}
 
-		if hasCodeBlockMarker(line) {
-			inCodeBlock = !inCodeBlock
-			previousLine = line
-
-			continue
+	// Reference links are checked through their definitions
+	for _, definition := range document.Definitions {
+		if link.IsRelative(definition.Destination) {
+			links = append(links, newLink(document, definition.Destination, definition.Position, definition.End))
 		}
+	}
 
-		if inCodeBlock {
-			previousLine = line
-
-			continue
+	// Raw HTML such as <img src="./logo.png">, comments are skipped
+	for _, html := range document.HTML {
+		for _, attribute := range html.Attributes() {
+			links = append(links, htmlLinks(document, attribute)...)
 		}
+	}
 
-		if extractAltHeading(&anchors, line, previousLine, anchorCount) {
-			previousLine = line
+	slices.SortStableFunc(links, func(a, b link.Link) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
 
-			continue
-		}
+	return links
+}
 
-		if extractHeading(&anchors, line, anchorCount) {
-			previousLine = line
+func htmlLinks(document *commonmark.Document, attribute commonmark.Attribute) []link.Link {
+	links := []link.Link{}
 
-			continue
+	switch attribute.Name {
+	case "href", "src":
+		if link.IsRelative(attribute.Value) {
+			links = append(links, newLink(document, attribute.Value, attribute.Position, attribute.End))
 		}
+	case "srcset":
+		// Image candidates such as ./logo.png 1x, ./logo@2x.png 2x
+		offset := 0
 
-		extractLink(&links, line, lineNumber)
-		previousLine = line
-	}
+		for candidate := range strings.SplitSeq(attribute.Value, ",") {
+			url := strings.TrimLeft(candidate, " \t\n\f\r")
+			urlOffset := offset + len(candidate) - len(url)
 
-	if err := scanner.Err(); err != nil {
-		return Result{}, fmt.Errorf("error scanning file: %w", err)
+			if fields := strings.Fields(url); len(fields) > 0 && link.IsRelative(fields[0]) {
+				start, end := attribute.PositionOf(urlOffset), attribute.PositionOf(urlOffset+len(fields[0]))
+				links = append(links, newLink(document, fields[0], start, end))
+			}
+
+			offset += len(candidate) + 1 // +1 for the comma
+		}
 	}
 
-	return Result{
-		Links:     links,
-		Anchors:   anchors,
-		LineCount: lineNumber,
-	}, nil
+	return links
 }
 
-func hasCodeBlockMarker(line string) bool {
-	trimmedLine := strings.TrimLeft(line, " \t")
-
-	// Must start with ```
-	if !strings.HasPrefix(trimmedLine, "```") {
-		return false
-	}
-
-	afterMarker := trimmedLine[3:]
+func extractUndefinedReferences(document *commonmark.Document) []link.Link {
+	undefined := []link.Link{}
 
-	// Ensure code block is not closed on the same line
-	if !strings.Contains(afterMarker, "```") {
-		return true
+	for _, reference := range document.References {
+		if !reference.Defined {
+			undefined = append(undefined, newLink(document, reference.Label, reference.Position, reference.End))
+		}
 	}
 
-	return false
+	return undefined
 }
 
-func extractLink(links *[]link.Link, line string, lineNumber int) {
-	matches := relativeLinkPattern.FindAllStringIndex(line, -1)
-	for _, match := range matches {
-		start, end := match[0], match[1]
-		// Extract URL without ]( and )
-		rawURL := line[start+2 : end-1]
-		// Skip ](
-		colPosition := start + 2
-
-		// Handle alt text in quotes if present
-		urlText := rawURL
-		if idx := strings.IndexAny(rawURL, "\"'"); idx != -1 {
-			// Only take the part before the quote
-			urlText = strings.TrimSpace(rawURL[:idx])
+func extractUnusedDefinitions(document *commonmark.Document) []link.Link {
+	used := make(map[string]bool)
+
+	for _, reference := range document.References {
+		if reference.Defined {
+			used[reference.Label] = true
 		}
+	}
 
-		path, anchorText := link.SplitLinkAndAnchor(urlText)
+	unused := []link.Link{}
 
-		*links = append(*links, link.Link{
-			URL:         urlText,
-			Line:        lineNumber,
-			Column:      colPosition + 1, // +1 because columns start at 1
-			Path:        path,
-			Anchor:      anchorText,
-			LineContent: line,
-		})
+	for _, definition := range document.Definitions {
+		// Only the first definition of a label can ever be used
+		if definition.Duplicate || !used[definition.Label] {
+			unused = append(unused, newLink(document, definition.Destination, definition.Position, definition.End))
+		}
 	}
-}
 
-func extractHeading(anchors *[]string, line string, anchorCount map[string]int) bool {
-	if !strings.HasPrefix(line, "#") {
-		return false
-	}
+	return unused
+}
 
-	// Match 1 to 6 #s followed by a space
-	if !headingPattern.MatchString(line) {
-		return false
-	}
+func extractAnchors(document *commonmark.Document, slugger anchor.Slugger) ([]string, []int) {
+	anchors := []string{}
+	lines := []int{}
+	seen := make(map[string]int)
 
-	// Extract heading text without the leading #s
-	heading := headingTextPattern.ReplaceAllString(line, "")
+	for _, heading := range document.Headings {
+		lines = append(lines, heading.Position.Line)
 
-	// Remove trailing spaces
-	heading = strings.TrimRight(heading, " \t")
+		// An explicit id such as ## Title {#custom-id} replaces the generated anchor
+		if id, ok := attributeListID(heading.Text); ok {
+			// Generated anchors are compared against the same form, so that they avoid the explicit one
+			normalised := slugger.Normalise(id)
+			anchors = append(anchors, normalised)
 
-	// Remove markdown link syntax from heading
-	heading = markdownLinkPattern.ReplaceAllString(heading, "$1")
+			if _, taken := seen[normalised]; !taken {
+				seen[normalised] = 0
+			}
 
-	anchorText := anchor.GenerateAnchor(heading)
+			continue
+		}
 
-	// Handle duplicate anchors
-	if count := anchorCount[anchorText]; count > 0 {
-		*anchors = append(*anchors, fmt.Sprintf("%s-%d", anchorText, count))
-	} else {
-		*anchors = append(*anchors, anchorText)
+		// Each style has its own rule for duplicate anchors
+		anchors = append(anchors, slugger.Unique(slugger.Slug(heading.Text), seen))
 	}
 
-	anchorCount[anchorText]++
+	// Explicit anchors such as <a id="legacy-flag"></a> or <h2 id="...">
+	for _, html := range document.HTML {
+		for _, attribute := range html.Attributes() {
+			if attribute.Name == "id" || (attribute.Name == "name" && attribute.Tag == "a") {
+				anchors = append(anchors, slugger.Normalise(attribute.Value))
+				lines = append(lines, attribute.Position.Line)
+			}
+		}
+	}
 
-	return true
+	return anchors, lines
 }
 
-func extractAltHeading(anchors *[]string, currentLine string, previousLine string, anchorCount map[string]int) bool {
-	if previousLine != "" && !strings.HasPrefix(currentLine, "#") && headingAltPattern.MatchString(currentLine) {
-		// Remove trailing spaces
-		heading := strings.TrimRight(previousLine, " \t")
-
-		anchorText := anchor.GenerateAnchor(heading)
+// attributeListID returns the id of a kramdown or Python-Markdown attribute list ending a heading, like {#custom-id}
+func attributeListID(heading string) (string, bool) {
+	match := attributeListPattern.FindStringSubmatch(heading)
+	if match == nil {
+		return "", false
+	}
 
-		// Handle duplicate anchors
-		if count := anchorCount[anchorText]; count > 0 {
-			*anchors = append(*anchors, fmt.Sprintf("%s-%d", anchorText, count))
-		} else {
-			*anchors = append(*anchors, anchorText)
+	for _, field := range strings.Fields(match[1]) {
+		if id, ok := strings.CutPrefix(field, "#"); ok && id != "" {
+			return id, true
 		}
-
-		anchorCount[anchorText]++
-
-		return true
 	}
 
-	return false
+	return "", false
 }
@@ -0,0 +1,141 @@
+package scan
+
+import (
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/anttiharju/relcheck/internal/markdown/commonmark"
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/rule"
+)
+
+// Directives of suppression comments such as <!-- relcheck-disable-next-line target-not-found -->
+const (
+	DisableNextLine = "relcheck-disable-next-line"
+	Disable         = "relcheck-disable"
+	Enable          = "relcheck-enable"
+	DisableFile     = "relcheck-disable-file"
+)
+
+var suppressionPattern = regexp.MustCompile(
+	`<!--[ \t\r\n]*(relcheck-(?:disable-next-line|disable-file|disable|enable))\b([^>]*?)[ \t\r\n]*-->`)
+
+// Suppression hides broken links from FirstLine to LastLine, for all rules if Rules is empty
+type Suppression struct {
+	Directive   string
+	Rules       []string // Rule IDs
+	Line        int      // Of the comment
+	Column      int
+	EndColumn   int // Just after the comment, or the end of its line if it continues
+	LineContent string
+	FirstLine   int
+	LastLine    int
+}
+
+// Covers tells whether the suppression hides a broken link of the rule on the line
+func (s Suppression) Covers(line int, brokenRule rule.Rule) bool {
+	if line < s.FirstLine || line > s.LastLine {
+		return false
+	}
+
+	return len(s.Rules) == 0 || slices.Contains(s.Rules, brokenRule.ID)
+}
+
+// extractSuppressions also returns the rule names that match no rule, pointing at their comments
+func extractSuppressions(document *commonmark.Document) ([]Suppression, []link.Link) {
+	suppressions := []Suppression{}
+	unknownRules := []link.Link{}
+	open := []int{} // Indices of relcheck-disable comments waiting for relcheck-enable
+
+	for _, html := range document.HTML {
+		for _, match := range suppressionPattern.FindAllStringSubmatchIndex(html.Content, -1) {
+			position := html.Position(match[0])
+			directive := html.Content[match[2]:match[3]]
+
+			rules, unknown := suppressedRules(html.Content[match[4]:match[5]])
+			lineContent := document.Lines[position.Line-1]
+
+			endColumn := len(lineContent) + 1
+			if end := html.Position(match[1]); end.Line == position.Line {
+				endColumn = end.Column
+			}
+
+			for _, name := range unknown {
+				unknownRules = append(unknownRules, link.Link{
+					URL:         name,
+					Line:        position.Line,
+					Column:      position.Column,
+					EndColumn:   endColumn,
+					LineContent: lineContent,
+				})
+			}
+
+			// Naming only unknown rules must not mean every rule
+			if len(rules) == 0 && len(unknown) > 0 {
+				continue
+			}
+
+			suppression := Suppression{
+				Directive:   directive,
+				Rules:       rules,
+				Line:        position.Line,
+				Column:      position.Column,
+				EndColumn:   endColumn,
+				LineContent: lineContent,
+				FirstLine:   1,
+				LastLine:    math.MaxInt,
+			}
+
+			switch directive {
+			case DisableNextLine:
+				suppression.FirstLine = position.Line + 1
+				suppression.LastLine = position.Line + 1
+			case Disable:
+				suppression.FirstLine = position.Line
+				open = append(open, len(suppressions))
+			case Enable:
+				// Ends the disabled ranges of the same rules, all of them without rules
+				open = slices.DeleteFunc(open, func(index int) bool {
+					if len(rules) > 0 && !slices.Equal(suppressions[index].Rules, rules) {
+						return false
+					}
+
+					suppressions[index].LastLine = position.Line
+
+					return true
+				})
+
+				continue
+			case DisableFile:
+			}
+
+			suppressions = append(suppressions, suppression)
+		}
+	}
+
+	return suppressions, unknownRules
+}
+
+// suppressedRules parses the rule IDs or names following a directive, separated by spaces or commas,
+// into the IDs of known rules and the names that match none
+func suppressedRules(text string) ([]string, []string) {
+	rules := []string{}
+	unknown := []string{}
+
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	}) {
+		known, ok := rule.Lookup(field)
+		if !ok {
+			unknown = append(unknown, field)
+
+			continue
+		}
+
+		rules = append(rules, known.ID)
+	}
+
+	return rules, unknown
+}
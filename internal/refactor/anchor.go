@@ -0,0 +1,159 @@
+package refactor
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/anttiharju/relcheck/internal/check"
+	"github.com/anttiharju/relcheck/internal/markdown/anchor"
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/markdown/scan"
+)
+
+var (
+	atxHeadingPattern    = regexp.MustCompile(`^( {0,3}#{1,6}[ \t]+)(.*?)([ \t]+#+)?[ \t]*$`)
+	attributeListPattern = regexp.MustCompile(`\{:?[^{}]*#([^\s{}]+)[^{}]*\}[ \t]*$`)
+	htmlIDPattern        = regexp.MustCompile(`(?i)\b(?:id|name)[ \t]*=[ \t]*(?:"([^"]*)"|'([^']*)'|([^\s"'=<>` + "`" + `]+))`)
+	setextUnderline      = regexp.MustCompile(`^ {0,3}(?:=+|-+)[ \t]*$`)
+	containerPattern     = regexp.MustCompile(`^(?:[ \t]*(?:>|[-+*](?:[ \t]|$)|[0-9]{1,9}[.)](?:[ \t]|$)))*[ \t]*`)
+	lineAnchorPattern    = regexp.MustCompile(`^L\d+$`)
+)
+
+var errHeadingNotEditable = errors.New("unable to find the text of the heading to rename")
+
+// RenameAnchor renames the heading or explicit anchor of file that links refer to as oldAnchor,
+// and rewrites every link to an anchor of the file that changes with it. Headings take newName as their text,
+// explicit anchors such as {#id} or <a id="id"> take it as their id.
+func RenameAnchor(opts Options, file, oldAnchor, newName string) (Changes, Edit, error) {
+	content, err := opts.Read(file)
+	if err != nil {
+		return nil, Edit{}, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	before, err := scan.Content(content, opts.Slugger)
+	if err != nil {
+		return nil, Edit{}, fmt.Errorf("failed to scan %s: %w", file, err)
+	}
+
+	index := anchor.Index(opts.Slugger, before.Anchors, oldAnchor)
+	if index < 0 || index >= len(before.AnchorLines) {
+		return nil, Edit{}, fmt.Errorf("no heading of %s has the anchor %q", file, oldAnchor)
+	}
+
+	lines := strings.Split(string(content), "\n")
+
+	heading, err := headingEdit(opts.Slugger, lines, before.AnchorLines[index], before.Anchors[index], newName)
+	if err != nil {
+		return nil, Edit{}, err
+	}
+
+	renamedContent, err := Apply(content, []Edit{heading})
+	if err != nil {
+		return nil, Edit{}, fmt.Errorf("failed to edit %s: %w", file, err)
+	}
+
+	// Duplicate suffixes such as -1 may move to other headings, so every anchor is compared
+	after, err := scan.Content(renamedContent, opts.Slugger)
+	if err != nil || len(after.Anchors) != len(before.Anchors) {
+		return nil, Edit{}, fmt.Errorf("renaming to %q changes the structure of %s", newName, file)
+	}
+
+	renamed := make(map[string]string)
+
+	for i, old := range before.Anchors {
+		if after.Anchors[i] != old {
+			renamed[old] = after.Anchors[i]
+		}
+	}
+
+	changes := Changes{}
+	changes.add(file, heading)
+
+	// Links within the file count too, even when Git does not know it yet
+	opts.Files = append(slices.Clone(opts.Files), file)
+
+	err = linkEdits(opts, changes, func(linkFile string, l link.Link) (string, bool) {
+		if l.Anchor == "" || lineAnchorPattern.MatchString(l.Anchor) {
+			return "", false
+		}
+
+		path, _, _ := strings.Cut(l.Path, "?")
+
+		target, ok := check.Target(opts.Root, linkFile, path)
+		if !ok || !samePath(target, file) {
+			return "", false
+		}
+
+		i := anchor.Index(opts.Slugger, before.Anchors, l.Anchor)
+		if i < 0 {
+			return "", false
+		}
+
+		newAnchor, ok := renamed[before.Anchors[i]]
+		if !ok {
+			return "", false
+		}
+
+		// Only the fragment changes, the path keeps its relative form
+		return l.URL[:strings.IndexByte(l.URL, '#')+1] + newAnchor, true
+	})
+	if err != nil {
+		return nil, Edit{}, err
+	}
+
+	return changes, heading, nil
+}
+
+// headingEdit replaces the text of the heading on the line, or the explicit anchor on it
+func headingEdit(slugger anchor.Slugger, lines []string, lineNumber int, oldAnchor, newName string) (Edit, error) {
+	line := strings.TrimSuffix(lines[lineNumber-1], "\r")
+	edit := Edit{Line: lineNumber, Column: 0, EndColumn: 0, NewText: newName, LineContent: line}
+
+	// An explicit id such as ## Title {#custom-id}
+	if match := attributeListPattern.FindStringSubmatchIndex(line); match != nil &&
+		slugger.Normalise(line[match[2]:match[3]]) == oldAnchor {
+		edit.Column, edit.EndColumn = match[2]+1, match[3]+1
+
+		return edit, nil
+	}
+
+	// An HTML id such as <a id="legacy-flag"></a>
+	for _, match := range htmlIDPattern.FindAllStringSubmatchIndex(line, -1) {
+		for group := 2; group < len(match); group += 2 {
+			if match[group] >= 0 && slugger.Normalise(line[match[group]:match[group+1]]) == oldAnchor {
+				edit.Column, edit.EndColumn = match[group]+1, match[group+1]+1
+
+				return edit, nil
+			}
+		}
+	}
+
+	// Headings within lists or quotes follow their markers, as in > ## Title or - Title
+	prefix := len(containerPattern.FindString(line))
+
+	if match := atxHeadingPattern.FindStringSubmatchIndex(line[prefix:]); match != nil {
+		edit.Column, edit.EndColumn = prefix+match[4]+1, prefix+match[5]+1
+
+		return edit, nil
+	}
+
+	// A setext heading, whose text is underlined with = or -
+	if lineNumber < len(lines) && isSetextUnderline(strings.TrimSuffix(lines[lineNumber], "\r")) {
+		text := strings.TrimSpace(line[prefix:])
+		start := prefix + strings.Index(line[prefix:], text)
+		edit.Column, edit.EndColumn = start+1, start+len(text)+1
+
+		return edit, nil
+	}
+
+	return Edit{}, errHeadingNotEditable
+}
+
+// isSetextUnderline reports whether line underlines a heading, within a quote or list too.
+// A lone - is not taken for a list marker.
+func isSetextUnderline(line string) bool {
+	return setextUnderline.MatchString(line) || setextUnderline.MatchString(line[len(containerPattern.FindString(line)):])
+}
@@ -0,0 +1,123 @@
+package refactor
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const diffContext = 3 // Lines around each change, as with diff -u
+
+// Diff writes the changes and moves as a unified diff in the format of git diff, without making them
+func Diff(w io.Writer, changes Changes, moves Moves, read func(path string) ([]byte, error)) error {
+	paths := slices.Collect(maps.Keys(changes))
+	for path := range moves {
+		if _, ok := changes[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		newPath := cmp.Or(moves[path], path)
+		oldName, newName := "a/"+filepath.ToSlash(path), "b/"+filepath.ToSlash(newPath)
+
+		fmt.Fprintf(w, "diff --git %s %s\n", oldName, newName)
+
+		if newPath != path {
+			fmt.Fprintf(w, "rename from %s\nrename to %s\n", filepath.ToSlash(path), filepath.ToSlash(newPath))
+		}
+
+		edits, ok := changes[path]
+		if !ok {
+			continue
+		}
+
+		content, err := read(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		edited, err := Apply(content, edits)
+		if err != nil {
+			return fmt.Errorf("failed to edit %s: %w", path, err)
+		}
+
+		fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName)
+		writeHunks(w, string(content), string(edited))
+	}
+
+	return nil
+}
+
+// writeHunks compares line by line, which works as edits never add or remove lines
+func writeHunks(w io.Writer, before, after string) {
+	oldLines := diffLines(before)
+	newLines := diffLines(after)
+
+	changed := []int{}
+
+	for i := range oldLines {
+		if oldLines[i] != newLines[i] {
+			changed = append(changed, i)
+		}
+	}
+
+	for len(changed) > 0 {
+		// A hunk takes every change whose context touches the previous one
+		last := 0
+		for last+1 < len(changed) && changed[last+1]-changed[last] <= 2*diffContext+1 {
+			last++
+		}
+
+		start := max(changed[0]-diffContext, 0)
+		end := min(changed[last]+diffContext+1, len(oldLines))
+
+		fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", start+1, end-start, start+1, end-start)
+
+		for i := start; i < end; {
+			if oldLines[i] == newLines[i] {
+				fmt.Fprintf(w, " %s", oldLines[i])
+
+				i++
+
+				continue
+			}
+
+			// Removed lines come before the added ones
+			run := i
+			for run < end && oldLines[run] != newLines[run] {
+				run++
+			}
+
+			for _, line := range oldLines[i:run] {
+				fmt.Fprintf(w, "-%s", line)
+			}
+
+			for _, line := range newLines[i:run] {
+				fmt.Fprintf(w, "+%s", line)
+			}
+
+			i = run
+		}
+
+		changed = changed[last+1:]
+	}
+}
+
+// diffLines splits content into lines that end with a newline, marking a missing one like diff does
+func diffLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+
+	lines[len(lines)-1] += "\n\\ No newline at end of file\n"
+
+	return lines
+}
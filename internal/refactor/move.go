@@ -0,0 +1,235 @@
+package refactor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anttiharju/relcheck/internal/check"
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+)
+
+// Moves holds the new path of every moved file by its old path
+type Moves map[string]string
+
+var errDestinationExists = errors.New("destination already exists")
+
+// move maps paths within the source to the destination, both absolute
+type move struct {
+	source      string
+	destination string
+}
+
+func (m move) apply(path string) (string, bool) {
+	if path == m.source {
+		return m.destination, true
+	}
+
+	if rest, ok := strings.CutPrefix(path, m.source+string(filepath.Separator)); ok {
+		return filepath.Join(m.destination, rest), true
+	}
+
+	return path, false
+}
+
+// Destination returns where source ends up when moved to destination, which is within destination
+// if it is an existing directory, as with git mv
+func Destination(source, destination string) (string, error) {
+	info, err := os.Stat(destination)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return destination, nil
+	case err != nil:
+		return "", fmt.Errorf("failed to inspect %s: %w", destination, err)
+	case info.IsDir():
+		destination = filepath.Join(destination, filepath.Base(source))
+		if _, err := os.Stat(destination); err == nil {
+			return "", fmt.Errorf("%s: %w", destination, errDestinationExists)
+		}
+
+		return destination, nil
+	}
+
+	return "", fmt.Errorf("%s: %w", destination, errDestinationExists)
+}
+
+// Move plans moving the file or directory source to destination as returned by Destination.
+// Links into the moved files are rewritten, as are the links within them that now start from another directory.
+// Anchors, query strings, titles and percent-encoding are kept.
+func Move(opts Options, source, destination string) (Changes, Moves, error) {
+	absoluteSource, err := filepath.Abs(source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve %s: %w", source, err)
+	}
+
+	absoluteDestination, err := filepath.Abs(destination)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve %s: %w", destination, err)
+	}
+
+	mv := move{source: absoluteSource, destination: absoluteDestination}
+
+	moves, err := movedFiles(absoluteSource, absoluteDestination)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := ""
+	if opts.Root != "" {
+		root, _ = filepath.Abs(opts.Root)
+	}
+
+	changes := Changes{}
+
+	err = linkEdits(opts, changes, func(file string, l link.Link) (string, bool) {
+		// Anchors within the same file stay valid wherever it goes
+		if l.Path == "" {
+			return "", false
+		}
+
+		path, query, _ := strings.Cut(l.Path, "?")
+
+		target, ok := check.Target(opts.Root, file, path)
+		if !ok {
+			return "", false
+		}
+
+		absoluteTarget, _ := filepath.Abs(target)
+		absoluteFile, _ := filepath.Abs(file)
+		newTarget, targetMoved := mv.apply(absoluteTarget)
+		newFile, fileMoved := mv.apply(absoluteFile)
+
+		var newPath string
+
+		switch {
+		case !targetMoved && !fileMoved:
+			return "", false
+		case link.IsRootRelative(path):
+			if !targetMoved || root == "" {
+				return "", false
+			}
+
+			relative, err := filepath.Rel(root, newTarget)
+			if err != nil {
+				return "", false
+			}
+
+			newPath = "/" + filepath.ToSlash(relative)
+		default:
+			// Links between moved files may still work from the new directory
+			if unchanged, ok := check.Target(opts.Root, newFile, path); ok && samePath(unchanged, newTarget) {
+				return "", false
+			}
+
+			relative, err := filepath.Rel(filepath.Dir(newFile), newTarget)
+			if err != nil {
+				return "", false
+			}
+
+			newPath = relativeForm(path, filepath.ToSlash(relative))
+		}
+
+		newPath = encodeLike(path, newPath)
+		if query != "" {
+			newPath += "?" + query
+		}
+
+		return newPath + l.URL[len(l.Path):], true
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return changes, moves, nil
+}
+
+// movedFiles lists every file moved along with source, relative to the working directory like Options.Files
+// whichever way source and destination are given, so that the paths match those of Changes
+func movedFiles(source, destination string) (Moves, error) {
+	moves := Moves{}
+
+	err := filepath.WalkDir(source, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		relative, err := filepath.Rel(source, path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", path, err)
+		}
+
+		moves[workingPath(path)] = workingPath(filepath.Join(destination, relative))
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", source, err)
+	}
+
+	return moves, nil
+}
+
+// relativeForm keeps the ./ prefix and trailing slash of the original path, like ./docs/ or guides/
+func relativeForm(original, path string) string {
+	if strings.HasPrefix(original, "./") && !strings.HasPrefix(path, "../") {
+		path = "./" + path
+	}
+
+	if strings.HasSuffix(original, "/") && !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	return path
+}
+
+// encodeLike percent-encodes the characters of path that original has encoded, like %20 for spaces.
+// Spaces are encoded regardless if original has none, as they would end the link.
+func encodeLike(original, path string) string {
+	encoded := make(map[rune]bool)
+
+	for rest := original; ; {
+		start := strings.IndexByte(rest, '%')
+		if start < 0 {
+			break
+		}
+
+		// A run of escapes, as characters such as ä take several
+		end := start
+		for end+2 < len(rest) && rest[end] == '%' {
+			end += 3
+		}
+
+		if decoded, err := url.PathUnescape(rest[start:end]); err == nil {
+			for _, r := range decoded {
+				encoded[r] = true
+			}
+		}
+
+		rest = rest[max(end, start+1):]
+	}
+
+	if !strings.Contains(original, " ") {
+		encoded[' '] = true
+	}
+
+	var builder strings.Builder
+
+	for _, r := range path {
+		if encoded[r] {
+			builder.WriteString(url.PathEscape(string(r)))
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
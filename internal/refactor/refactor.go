@@ -0,0 +1,309 @@
+// Package refactor changes Markdown files while keeping the links into them working.
+package refactor
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anttiharju/relcheck/internal/markdown/anchor"
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/markdown/scan"
+)
+
+// Edit replaces the bytes from Column up to EndColumn of a line
+type Edit struct {
+	Line        int // 1-based
+	Column      int // 1-based byte column
+	EndColumn   int // Exclusive
+	NewText     string
+	LineContent string // Before the edit, for editors that count columns differently
+}
+
+// Matches the entities CommonMark resolves within link destinations, such as &amp; or &#35;
+var entityPattern = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[a-zA-Z][a-zA-Z0-9]{1,31});`)
+
+// Changes holds the edits of each file by path
+type Changes map[string][]Edit
+
+// Edited holds the content of each changed file by path, with the edits made
+type Edited map[string]editedFile
+
+type editedFile struct {
+	content []byte
+	perm    fs.FileMode
+}
+
+var errEditNotApplicable = errors.New("the edits overlap or no longer match the file")
+
+// Options tell which files may link to the changed ones and how to read them,
+// as editors have content that is not saved yet
+type Options struct {
+	Root    string
+	Slugger anchor.Slugger
+	Files   []string
+	Read    func(path string) ([]byte, error)
+	Skip    func(file string, l link.Link) // Told of links that need rewriting but cannot be, nil to skip them silently
+}
+
+// add keeps the edits of a file under one path however it was given, such as by Git and on the command line
+func (c Changes) add(path string, edit Edit) {
+	path = workingPath(path)
+	c[path] = append(c[path], edit)
+}
+
+// workingPath makes a path relative to the working directory like those listed by Git, or keeps it as given
+func workingPath(path string) string {
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	relative, err := filepath.Rel(workingDirectory, absolute)
+	if err != nil {
+		return path
+	}
+
+	return relative
+}
+
+// Apply returns content with the edits made, failing if they overlap or the text they replace has changed
+func Apply(content []byte, edits []Edit) ([]byte, error) {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+
+	// From the end, so that earlier columns stay valid
+	sorted := slices.SortedFunc(slices.Values(edits), func(a, b Edit) int {
+		return cmp.Or(cmp.Compare(b.Line, a.Line), cmp.Compare(b.Column, a.Column))
+	})
+
+	for i, edit := range sorted {
+		if !fits(lines, edit) || (i > 0 && sorted[i-1].Line == edit.Line && sorted[i-1].Column < edit.EndColumn) {
+			return nil, fmt.Errorf("line %d: %w", edit.Line, errEditNotApplicable)
+		}
+
+		line := lines[edit.Line-1]
+		edited := slices.Concat(line[:edit.Column-1], []byte(edit.NewText), line[edit.EndColumn-1:])
+		lines[edit.Line-1] = edited
+	}
+
+	return bytes.Join(lines, nil), nil
+}
+
+// fits reports whether the edit replaces the same text as when it was planned
+func fits(lines [][]byte, edit Edit) bool {
+	if edit.Line < 1 || edit.Line > len(lines) || edit.Column < 1 || edit.Column > edit.EndColumn {
+		return false
+	}
+
+	line, end := lines[edit.Line-1], edit.EndColumn-1
+
+	return end <= len(line) && end <= len(edit.LineContent) &&
+		string(line[edit.Column-1:end]) == edit.LineContent[edit.Column-1:end]
+}
+
+// Prepare makes the changes in memory, so that nothing is written unless every file can be edited
+func Prepare(changes Changes) (Edited, error) {
+	edited := make(Edited, len(changes))
+
+	for path, edits := range changes {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect %s: %w", path, err)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		content, err = Apply(content, edits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to edit %s: %w", path, err)
+		}
+
+		edited[path] = editedFile{content: content, perm: info.Mode().Perm()}
+	}
+
+	return edited, nil
+}
+
+// Write writes the edited files to disk, at their new paths if they were moved
+func (e Edited) Write(moves Moves) error {
+	for path, file := range e {
+		path = cmp.Or(moves[path], path)
+
+		if err := os.WriteFile(path, file.content, file.perm); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// linkEdits rewrites the links of every file that rewrite returns a new URL for
+func linkEdits(opts Options, changes Changes, rewrite func(file string, l link.Link) (string, bool)) error {
+	seen := make(map[string]bool, len(opts.Files))
+
+	for _, file := range opts.Files {
+		// The same file may be listed under different paths, such as by Git and by an editor
+		absolute, err := filepath.Abs(file)
+		if err != nil || seen[absolute] {
+			continue
+		}
+
+		seen[absolute] = true
+
+		content, err := opts.Read(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		result, err := scan.Content(content, opts.Slugger)
+		if err != nil {
+			return fmt.Errorf("failed to scan %s: %w", file, err)
+		}
+
+		for _, l := range result.Links {
+			url, ok := rewrite(file, l)
+			if !ok || url == l.URL {
+				continue
+			}
+
+			edit, ok := sourceEdit(l, url)
+			if !ok {
+				if opts.Skip != nil {
+					opts.Skip(file, l)
+				}
+
+				continue
+			}
+
+			changes.add(file, edit)
+		}
+	}
+
+	return nil
+}
+
+// sourceEdit replaces the part of the source text of a link that differs between its URL and url,
+// so that escapes and entities around it are kept. Sources that do not spell out the URL on one line cannot be edited.
+func sourceEdit(l link.Link, url string) (Edit, bool) {
+	start, end := l.Column-1, l.EndColumn-1
+	if start < 0 || start > end || end > len(l.LineContent) {
+		return Edit{}, false
+	}
+
+	source := l.LineContent[start:end]
+
+	// HTML attributes have no backslash escapes
+	for _, backslashes := range []bool{true, false} {
+		decoded, offsets := decodeSource(source, backslashes)
+		if decoded != l.URL {
+			continue
+		}
+
+		prefix := commonPrefix(l.URL, url)
+		suffix := commonSuffix(l.URL[prefix:], url[prefix:])
+
+		// Escapes and entities are replaced whole
+		for offsets[prefix] < 0 {
+			prefix--
+		}
+
+		for offsets[len(l.URL)-suffix] < 0 {
+			suffix--
+		}
+
+		return Edit{
+			Line:        l.Line,
+			Column:      l.Column + offsets[prefix],
+			EndColumn:   l.Column + offsets[len(l.URL)-suffix],
+			NewText:     url[prefix : len(url)-suffix],
+			LineContent: l.LineContent,
+		}, true
+	}
+
+	return Edit{}, false
+}
+
+// decodeSource resolves the escapes and entities of source, returning where each byte of the result is in source,
+// or -1 for the bytes after the first of an escape or entity. The end of source is appended.
+func decodeSource(source string, backslashes bool) (string, []int) {
+	var decoded strings.Builder
+
+	offsets := make([]int, 0, len(source)+1)
+
+	for i := 0; i < len(source); {
+		unit, length := source[i:i+1], 1
+
+		if entity := entityPattern.FindString(source[i:]); entity != "" {
+			unit, length = html.UnescapeString(entity), len(entity)
+		} else if backslashes && source[i] == '\\' && i+1 < len(source) && isASCIIPunctuation(source[i+1]) {
+			unit, length = source[i+1:i+2], 2
+		}
+
+		offsets = append(offsets, i)
+		for range len(unit) - 1 {
+			offsets = append(offsets, -1)
+		}
+
+		decoded.WriteString(unit)
+
+		i += length
+	}
+
+	return decoded.String(), append(offsets, len(source))
+}
+
+func isASCIIPunctuation(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// commonPrefix is the length of the longest common prefix of a and b that ends between characters
+func commonPrefix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+
+	for n > 0 && ((n < len(a) && !utf8.RuneStart(a[n])) || (n < len(b) && !utf8.RuneStart(b[n]))) {
+		n--
+	}
+
+	return n
+}
+
+// commonSuffix is the length of the longest common suffix of a and b that starts between characters
+func commonSuffix(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[len(a)-1-n] == b[len(b)-1-n] {
+		n++
+	}
+
+	for n > 0 && !utf8.RuneStart(a[len(a)-n]) {
+		n--
+	}
+
+	return n
+}
+
+func samePath(a, b string) bool {
+	absoluteA, errA := filepath.Abs(a)
+	absoluteB, errB := filepath.Abs(b)
+
+	return errA == nil && errB == nil && absoluteA == absoluteB
+}
@@ -0,0 +1,218 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+)
+
+type githubFile struct {
+	path   string
+	valid  int
+	broken int
+}
+
+type githubFailure struct {
+	filename string
+	link     link.Link
+	reason   string
+}
+
+// GitHubReporter adds GitHub Actions annotations to the text output and writes a job summary
+type GitHubReporter struct {
+	*TextReporter
+
+	summaryPath string // GITHUB_STEP_SUMMARY, empty to skip the summary
+	repository  string // Root that annotation paths are relative to, empty to keep the paths as given
+	files       []*githubFile
+	fileIndex   map[string]*githubFile
+	failures    []githubFailure
+}
+
+func NewGitHub(output io.Writer, summaryPath, repository string, verbose, forceColors bool) *GitHubReporter {
+	return &GitHubReporter{
+		TextReporter: NewText(output, verbose, forceColors),
+		summaryPath:  summaryPath,
+		repository:   repository,
+		files:        []*githubFile{},
+		fileIndex:    make(map[string]*githubFile),
+		failures:     []githubFailure{},
+	}
+}
+
+func (r *GitHubReporter) file(filename string) *githubFile {
+	if file, ok := r.fileIndex[filename]; ok {
+		return file
+	}
+
+	file := &githubFile{path: filename, valid: 0, broken: 0}
+	r.fileIndex[filename] = file
+	r.files = append(r.files, file)
+
+	return file
+}
+
+func (r *GitHubReporter) FileNotFound(filename string) {
+	r.TextReporter.FileNotFound(filename)
+	r.file(filename)
+	r.failures = append(r.failures, githubFailure{filename: filename, link: link.Link{}, reason: "file not found"})
+
+	fmt.Fprintf(r.output, "::error file=%s,title=%s::%s\n",
+		escapeProperty(r.annotationPath(filename)), escapeProperty("relcheck: file not found"), escapeData("File not found: "+filename))
+}
+
+func (r *GitHubReporter) ScanError(filename string, err error) {
+	r.TextReporter.ScanError(filename, err)
+	r.file(filename)
+	r.failures = append(r.failures, githubFailure{filename: filename, link: link.Link{}, reason: err.Error()})
+
+	fmt.Fprintf(r.output, "::error file=%s,title=%s::%s\n",
+		escapeProperty(r.annotationPath(filename)), escapeProperty("relcheck: could not process file"), escapeData(err.Error()))
+}
+
+func (r *GitHubReporter) NoLinks(filename string) {
+	r.TextReporter.NoLinks(filename)
+	r.file(filename)
+}
+
+func (r *GitHubReporter) ValidLink(filename string, validLink link.Link) {
+	r.TextReporter.ValidLink(filename, validLink)
+	r.file(filename).valid++
+}
+
+func (r *GitHubReporter) BrokenLink(filename string, brokenLink link.Link, errorType string, lineContent string) {
+	r.TextReporter.BrokenLink(filename, brokenLink, errorType, lineContent)
+	r.file(filename).broken++
+	r.failures = append(r.failures, githubFailure{filename: filename, link: brokenLink, reason: errorType})
+
+	// GitHub counts columns in characters
+	column := codePointColumn(lineContent, brokenLink.Column)
+	endColumn := codePointColumn(lineContent, brokenLink.EndColumn)
+
+	command := "error"
+	if isWarning(brokenLink) {
+		command = "warning"
+	}
+
+	fmt.Fprintf(r.output, "::%s file=%s,line=%d,col=%d,endColumn=%d,title=%s::%s\n",
+		command, escapeProperty(r.annotationPath(filename)), brokenLink.Line, column, endColumn,
+		escapeProperty("relcheck: "+errorType),
+		escapeData(fmt.Sprintf("broken relative link (%s): %s", errorType, brokenLink.URL)))
+}
+
+// annotationPath makes a path relative to the repository root, as GitHub expects even when checking a subdirectory
+func (r *GitHubReporter) annotationPath(filename string) string {
+	path, _ := repositoryPath(r.repository, filename)
+
+	return path
+}
+
+// repositoryPath makes a path relative to the repository root with forward slashes, reporting whether it could.
+// Files outside of the repository, or behind a symbolic link, are kept as they are.
+func repositoryPath(repository, filename string) (string, bool) {
+	if repository == "" {
+		return filename, false
+	}
+
+	absolute, err := filepath.Abs(filename)
+	if err != nil {
+		return filename, false
+	}
+
+	relative, err := filepath.Rel(repository, absolute)
+	if err != nil || !filepath.IsLocal(relative) {
+		return filename, false
+	}
+
+	return filepath.ToSlash(relative), true
+}
+
+func (r *GitHubReporter) ValidLinks(filename string, count int, hasBrokenLinks bool) {
+	r.TextReporter.ValidLinks(filename, count, hasBrokenLinks)
+	r.file(filename)
+}
+
+func (r *GitHubReporter) Flush() error {
+	if err := r.TextReporter.Flush(); err != nil {
+		return err
+	}
+
+	if r.summaryPath == "" {
+		return nil
+	}
+
+	// The summary file is shared by every step of the job, so it is appended to
+	summary, err := os.OpenFile(r.summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open job summary: %w", err)
+	}
+	defer summary.Close()
+
+	if _, err := io.WriteString(summary, r.summary()); err != nil {
+		return fmt.Errorf("failed to write job summary: %w", err)
+	}
+
+	return nil
+}
+
+func (r *GitHubReporter) summary() string {
+	var builder strings.Builder
+
+	valid, broken := 0, 0
+
+	builder.WriteString("## relcheck\n\n")
+	builder.WriteString("| File | Valid links | Broken links |\n")
+	builder.WriteString("| --- | ---: | ---: |\n")
+
+	for _, file := range r.files {
+		fmt.Fprintf(&builder, "| %s | %d | %d |\n", escapeTableCell(file.path), file.valid, file.broken)
+
+		valid += file.valid
+		broken += file.broken
+	}
+
+	fmt.Fprintf(&builder, "\n%d files checked, %d valid and %d broken relative links.\n", len(r.files), valid, broken)
+
+	if len(r.failures) == 0 {
+		builder.WriteString("\n✓ All relative links are valid!\n")
+
+		return builder.String()
+	}
+
+	builder.WriteString("\n| Location | Reason | Link |\n")
+	builder.WriteString("| --- | --- | --- |\n")
+
+	for _, failure := range r.failures {
+		location := failure.filename
+		if failure.link.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d", failure.filename, failure.link.Line, failure.link.Column)
+		}
+
+		fmt.Fprintf(&builder, "| %s | %s | %s |\n",
+			escapeTableCell(location), escapeTableCell(failure.reason), escapeTableCell(failure.link.URL))
+	}
+
+	return builder.String()
+}
+
+// escapeData escapes the message of a workflow command
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command, such as file=
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+func escapeTableCell(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return "`" + strings.ReplaceAll(s, "|", "\\|") + "`"
+}
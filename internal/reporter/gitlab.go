@@ -0,0 +1,118 @@
+package reporter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/rule"
+)
+
+const (
+	gitlabSeverity      = "major"
+	gitlabWarning       = "minor"
+	gitlabFileErrorName = "file-error"
+)
+
+type gitlabLines struct {
+	Begin int `json:"begin"`
+}
+
+type gitlabLocation struct {
+	Path  string      `json:"path"`
+	Lines gitlabLines `json:"lines"`
+}
+
+type gitlabIssue struct {
+	Description string         `json:"description"`
+	CheckName   string         `json:"check_name"` //nolint:tagliatelle // defined by GitLab
+	Fingerprint string         `json:"fingerprint"`
+	Severity    string         `json:"severity"`
+	Location    gitlabLocation `json:"location"`
+}
+
+// GitLabReporter writes a GitLab Code Quality report for the merge request widget
+type GitLabReporter struct {
+	issues      []gitlabIssue
+	occurrences map[string]int
+	output      io.Writer
+}
+
+func NewGitLab(output io.Writer) *GitLabReporter {
+	return &GitLabReporter{
+		issues:      []gitlabIssue{},
+		occurrences: make(map[string]int),
+		output:      output,
+	}
+}
+
+// fingerprint leaves out line numbers, so that a finding keeps its identity when unrelated lines change.
+// Repeated identical findings within a file are told apart by their order.
+func (r *GitLabReporter) fingerprint(parts ...string) string {
+	key := strings.Join(parts, "\x00")
+	occurrence := r.occurrences[key]
+	r.occurrences[key]++
+
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%d", key, occurrence))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func (r *GitLabReporter) fileError(filename, description string) {
+	r.issues = append(r.issues, gitlabIssue{
+		Description: description,
+		CheckName:   gitlabFileErrorName,
+		Fingerprint: r.fingerprint(filename, gitlabFileErrorName),
+		Severity:    gitlabSeverity,
+		Location:    gitlabLocation{Path: filename, Lines: gitlabLines{Begin: 1}},
+	})
+}
+
+func (r *GitLabReporter) FileNotFound(filename string) {
+	r.fileError(filename, "File not found: "+filename)
+}
+
+func (r *GitLabReporter) ScanError(filename string, err error) {
+	r.fileError(filename, fmt.Sprintf("Could not process file %s: %v", filename, err))
+}
+
+func (r *GitLabReporter) NoLinks(_ string) {}
+
+func (r *GitLabReporter) ValidLink(_ string, _ link.Link) {}
+
+func (r *GitLabReporter) BrokenLink(filename string, brokenLink link.Link, errorType string, _ string) {
+	brokenRule, _ := rule.ForReason(errorType)
+
+	severity := gitlabSeverity
+	if isWarning(brokenLink) {
+		severity = gitlabWarning
+	}
+
+	r.issues = append(r.issues, gitlabIssue{
+		Description: fmt.Sprintf("broken relative link (%s): %s", errorType, brokenLink.URL),
+		CheckName:   brokenRule.Name,
+		Fingerprint: r.fingerprint(filename, brokenRule.ID, brokenLink.Anchor, brokenLink.URL),
+		Severity:    severity,
+		Location:    gitlabLocation{Path: filename, Lines: gitlabLines{Begin: brokenLink.Line}},
+	})
+}
+
+func (r *GitLabReporter) ValidLinks(_ string, _ int, _ bool) {}
+
+func (r *GitLabReporter) Success() {}
+
+func (r *GitLabReporter) Flush() error {
+	encoder := json.NewEncoder(r.output)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(r.issues); err != nil {
+		return fmt.Errorf("failed to write GitLab Code Quality report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package reporter
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Lines shown before and after each link
+const htmlContextLines = 2
+
+//go:embed html.tmpl
+var htmlTemplateSource string
+
+//nolint:gochecknoglobals
+var htmlTemplate = template.Must(template.New("report").Parse(htmlTemplateSource))
+
+type htmlLine struct {
+	Number  int
+	Text    string
+	Current bool
+}
+
+type htmlFile struct {
+	*jsonFile
+
+	Inbound  int // Valid links from other files
+	Outbound int // Links to anywhere
+}
+
+type htmlLink struct {
+	jsonLink
+
+	Context []htmlLine
+}
+
+type htmlReport struct {
+	Files  []htmlFile
+	Links  []htmlLink
+	Totals jsonTotals
+}
+
+// HTMLReporter writes a single, offline HTML page with every file and link, meant for documentation audits
+type HTMLReporter struct {
+	*JSONReporter
+
+	sources map[string][]string
+}
+
+func NewHTML(output io.Writer) *HTMLReporter {
+	return &HTMLReporter{
+		JSONReporter: NewJSON(output),
+		sources:      make(map[string][]string),
+	}
+}
+
+// context returns the lines around a link, reading each file once
+func (r *HTMLReporter) context(filename string, line int) []htmlLine {
+	lines, ok := r.sources[filename]
+	if !ok {
+		content, err := os.ReadFile(filename)
+		if err == nil {
+			lines = strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+		}
+
+		r.sources[filename] = lines
+	}
+
+	context := []htmlLine{}
+
+	for number := max(line-htmlContextLines, 1); number <= min(line+htmlContextLines, len(lines)); number++ {
+		context = append(context, htmlLine{Number: number, Text: lines[number-1], Current: number == line})
+	}
+
+	return context
+}
+
+func (r *HTMLReporter) Flush() error {
+	r.finish()
+
+	report := htmlReport{
+		Files:  make([]htmlFile, 0, len(r.document.Files)),
+		Links:  make([]htmlLink, 0, len(r.document.Links)),
+		Totals: r.document.Totals,
+	}
+
+	inbound := make(map[string]int)
+	outbound := make(map[string]int)
+
+	for _, l := range r.document.Links {
+		outbound[l.File]++
+
+		if l.Status == statusValid && filepath.Clean(l.Path) != filepath.Clean(l.File) {
+			inbound[filepath.Clean(l.Path)]++
+		}
+
+		report.Links = append(report.Links, htmlLink{jsonLink: l, Context: r.context(l.File, l.Line)})
+	}
+
+	for _, file := range r.document.Files {
+		report.Files = append(report.Files, htmlFile{
+			jsonFile: file,
+			Inbound:  inbound[filepath.Clean(file.Path)],
+			Outbound: outbound[file.Path],
+		})
+	}
+
+	if err := htmlTemplate.Execute(r.output, report); err != nil {
+		return fmt.Errorf("failed to write HTML report: %w", err)
+	}
+
+	return nil
+}
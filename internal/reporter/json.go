@@ -0,0 +1,183 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/rule"
+)
+
+// File statuses of the JSON document
+const (
+	statusValid    = "valid"
+	statusBroken   = "broken"
+	statusKnown    = "known" // Broken, but in the baseline
+	statusNotFound = "not found"
+	statusError    = "error"
+)
+
+type jsonFile struct {
+	Path   string   `json:"path"`
+	Status string   `json:"status"`
+	Valid  int      `json:"valid"`
+	Broken int      `json:"broken"`
+	Known  int      `json:"known"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+type jsonLink struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	URL      string `json:"url"`
+	Path     string `json:"path,omitempty"` // Resolved target
+	Anchor   string `json:"anchor,omitempty"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason,omitempty"`
+	Severity string `json:"severity,omitempty"` // error or warning for broken links
+	Fix      string `json:"fix,omitempty"`      // Suggested URL
+}
+
+type jsonTotals struct {
+	Files    int `json:"files"`
+	Links    int `json:"links"`
+	Valid    int `json:"valid"`
+	Broken   int `json:"broken"`
+	Warnings int `json:"warnings"` // Broken links that do not fail the check
+	Known    int `json:"known"`    // Broken links in the baseline
+	Errors   int `json:"errors"`
+}
+
+type jsonDocument struct {
+	Files  []*jsonFile `json:"files"`
+	Links  []jsonLink  `json:"links"`
+	Totals jsonTotals  `json:"totals"`
+}
+
+// JSONReporter collects every finding and writes them as one JSON document on Flush
+type JSONReporter struct {
+	document jsonDocument
+	files    map[string]*jsonFile
+	output   io.Writer
+}
+
+func NewJSON(output io.Writer) *JSONReporter {
+	return &JSONReporter{
+		document: jsonDocument{
+			Files:  []*jsonFile{},
+			Links:  []jsonLink{},
+			Totals: jsonTotals{Files: 0, Links: 0, Valid: 0, Broken: 0, Warnings: 0, Known: 0, Errors: 0},
+		},
+		files:  make(map[string]*jsonFile),
+		output: output,
+	}
+}
+
+func (r *JSONReporter) file(filename string) *jsonFile {
+	if file, ok := r.files[filename]; ok {
+		return file
+	}
+
+	file := &jsonFile{Path: filename, Status: statusValid, Valid: 0, Broken: 0, Known: 0, Errors: nil}
+	r.files[filename] = file
+	r.document.Files = append(r.document.Files, file)
+
+	return file
+}
+
+func (r *JSONReporter) addLink(filename string, l link.Link, status, reason string) {
+	severity := ""
+	if status == statusBroken {
+		severity = string(rule.Error)
+		if isWarning(l) {
+			severity = string(rule.Warning)
+			r.document.Totals.Warnings++
+		}
+	}
+
+	r.document.Links = append(r.document.Links, jsonLink{
+		File:     filename,
+		Line:     l.Line,
+		Column:   l.Column,
+		URL:      l.URL,
+		Path:     l.Target,
+		Anchor:   l.Anchor,
+		Status:   status,
+		Reason:   reason,
+		Severity: severity,
+		Fix:      l.Fix,
+	})
+}
+
+func (r *JSONReporter) FileNotFound(filename string) {
+	r.file(filename).Status = statusNotFound
+	r.document.Totals.Errors++
+}
+
+func (r *JSONReporter) ScanError(filename string, err error) {
+	file := r.file(filename)
+	file.Status = statusError
+	file.Errors = append(file.Errors, err.Error())
+	r.document.Totals.Errors++
+}
+
+func (r *JSONReporter) NoLinks(filename string) {
+	r.file(filename)
+}
+
+func (r *JSONReporter) ValidLink(filename string, validLink link.Link) {
+	r.file(filename).Valid++
+	r.addLink(filename, validLink, statusValid, "")
+}
+
+func (r *JSONReporter) BrokenLink(filename string, brokenLink link.Link, errorType string, _ string) {
+	file := r.file(filename)
+	file.Broken++
+
+	if file.Status == statusValid {
+		file.Status = statusBroken
+	}
+
+	r.addLink(filename, brokenLink, statusBroken, errorType)
+}
+
+// KnownLink lists a baselined link without marking its file broken, as it does not fail the check
+func (r *JSONReporter) KnownLink(filename string, knownLink link.Link, errorType string) {
+	r.file(filename).Known++
+	r.addLink(filename, knownLink, statusKnown, errorType)
+}
+
+func (r *JSONReporter) ValidLinks(filename string, _ int, _ bool) {
+	r.file(filename)
+}
+
+func (r *JSONReporter) Success() {}
+
+// finish adds up the totals once every file has been checked
+func (r *JSONReporter) finish() {
+	totals := &r.document.Totals
+	totals.Files = len(r.document.Files)
+	totals.Links = len(r.document.Links)
+
+	for _, file := range r.document.Files {
+		totals.Valid += file.Valid
+		totals.Broken += file.Broken
+		totals.Known += file.Known
+	}
+}
+
+func (r *JSONReporter) Flush() error {
+	r.finish()
+
+	encoder := json.NewEncoder(r.output)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(r.document); err != nil {
+		return fmt.Errorf("failed to write JSON report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/rule"
+)
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",cdata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Errors    int              `xml:"errors,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name          `xml:"testsuites"`
+	Name       string            `xml:"name,attr"`
+	Tests      int               `xml:"tests,attr"`
+	Failures   int               `xml:"failures,attr"`
+	Errors     int               `xml:"errors,attr"`
+	TestSuites []*junitTestSuite `xml:"testsuite"`
+}
+
+// JUnitReporter writes a JUnit XML report with a test suite per file and a test case per link
+type JUnitReporter struct {
+	suites     []*junitTestSuite
+	suiteIndex map[string]*junitTestSuite
+	output     io.Writer
+}
+
+func NewJUnit(output io.Writer) *JUnitReporter {
+	return &JUnitReporter{
+		suites:     []*junitTestSuite{},
+		suiteIndex: make(map[string]*junitTestSuite),
+		output:     output,
+	}
+}
+
+func (r *JUnitReporter) suite(filename string) *junitTestSuite {
+	if suite, ok := r.suiteIndex[filename]; ok {
+		return suite
+	}
+
+	suite := &junitTestSuite{Name: filename, Tests: 0, Failures: 0, Errors: 0, TestCases: []*junitTestCase{}}
+	r.suiteIndex[filename] = suite
+	r.suites = append(r.suites, suite)
+
+	return suite
+}
+
+func (r *JUnitReporter) addTestCase(filename string, testCase *junitTestCase) {
+	suite := r.suite(filename)
+	suite.Tests++
+	suite.TestCases = append(suite.TestCases, testCase)
+
+	if testCase.Failure != nil {
+		suite.Failures++
+	}
+
+	if testCase.Error != nil {
+		suite.Errors++
+	}
+}
+
+func linkTestCase(filename string, l link.Link) *junitTestCase {
+	return &junitTestCase{
+		Name:      fmt.Sprintf("%d:%d %s", l.Line, l.Column, l.URL),
+		ClassName: filename,
+		File:      filename,
+		Line:      l.Line,
+		Failure:   nil,
+		Error:     nil,
+		SystemOut: "",
+	}
+}
+
+func (r *JUnitReporter) fileError(filename, message string) {
+	r.addTestCase(filename, &junitTestCase{
+		Name:      filename,
+		ClassName: filename,
+		File:      filename,
+		Line:      0,
+		Failure:   nil,
+		Error:     &junitProblem{Message: message, Type: "", Text: ""},
+		SystemOut: "",
+	})
+}
+
+func (r *JUnitReporter) FileNotFound(filename string) {
+	r.fileError(filename, "file not found")
+}
+
+func (r *JUnitReporter) ScanError(filename string, err error) {
+	r.fileError(filename, fmt.Sprintf("could not process file: %v", err))
+}
+
+func (r *JUnitReporter) NoLinks(filename string) {
+	r.suite(filename)
+}
+
+func (r *JUnitReporter) ValidLink(filename string, validLink link.Link) {
+	r.addTestCase(filename, linkTestCase(filename, validLink))
+}
+
+func (r *JUnitReporter) BrokenLink(filename string, brokenLink link.Link, errorType string, lineContent string) {
+	brokenRule, _ := rule.ForReason(errorType)
+
+	text := fmt.Sprintf("%s:%d:%d: broken relative link (%s):\n%s\n",
+		filename, brokenLink.Line, brokenLink.Column, errorType, lineContent)
+
+	// Warnings pass, but keep their message
+	testCase := linkTestCase(filename, brokenLink)
+	if isWarning(brokenLink) {
+		testCase.SystemOut = "warning: " + text
+	} else {
+		testCase.Failure = &junitProblem{Message: errorType, Type: brokenRule.ID, Text: text}
+	}
+
+	r.addTestCase(filename, testCase)
+}
+
+func (r *JUnitReporter) ValidLinks(filename string, _ int, _ bool) {
+	r.suite(filename)
+}
+
+func (r *JUnitReporter) Success() {}
+
+func (r *JUnitReporter) Flush() error {
+	report := junitTestSuites{
+		XMLName:    xml.Name{Space: "", Local: "testsuites"},
+		Name:       "relcheck",
+		Tests:      0,
+		Failures:   0,
+		Errors:     0,
+		TestSuites: r.suites,
+	}
+
+	for _, suite := range r.suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+
+	if _, err := io.WriteString(r.output, xml.Header); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+
+	encoder := xml.NewEncoder(r.output)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+
+	if _, err := io.WriteString(r.output, "\n"); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/rule"
+)
+
+type rdjsonPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"` // In bytes, like relcheck
+}
+
+type rdjsonRange struct {
+	Start rdjsonPosition `json:"start"`
+	End   rdjsonPosition `json:"end"`
+}
+
+type rdjsonLocation struct {
+	Path  string       `json:"path"`
+	Range *rdjsonRange `json:"range,omitempty"`
+}
+
+type rdjsonSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type rdjsonCode struct {
+	Value string `json:"value"`
+}
+
+type rdjsonSuggestion struct {
+	Range rdjsonRange `json:"range"`
+	Text  string      `json:"text"`
+}
+
+type rdjsonDiagnostic struct {
+	Message     string             `json:"message"`
+	Location    rdjsonLocation     `json:"location"`
+	Severity    string             `json:"severity"`
+	Source      rdjsonSource       `json:"source"`
+	Code        *rdjsonCode        `json:"code,omitempty"`
+	Suggestions []rdjsonSuggestion `json:"suggestions,omitempty"`
+}
+
+// RDJSONLReporter writes reviewdog diagnostics, one JSON object per line as they are found
+type RDJSONLReporter struct {
+	encoder *json.Encoder
+	err     error // First write error, returned by Flush
+}
+
+func NewRDJSONL(output io.Writer) *RDJSONLReporter {
+	encoder := json.NewEncoder(output)
+	encoder.SetEscapeHTML(false)
+
+	return &RDJSONLReporter{
+		encoder: encoder,
+		err:     nil,
+	}
+}
+
+func (r *RDJSONLReporter) write(diagnostic rdjsonDiagnostic) {
+	if diagnostic.Severity == "" {
+		diagnostic.Severity = "ERROR"
+	}
+
+	diagnostic.Source = rdjsonSource{Name: "relcheck", URL: "https://github.com/anttiharju/relcheck"}
+
+	if err := r.encoder.Encode(diagnostic); err != nil && r.err == nil {
+		r.err = fmt.Errorf("failed to write rdjsonl report: %w", err)
+	}
+}
+
+func (r *RDJSONLReporter) FileNotFound(filename string) {
+	r.write(rdjsonDiagnostic{
+		Message:  "File not found: " + filename,
+		Location: rdjsonLocation{Path: filename, Range: nil},
+	})
+}
+
+func (r *RDJSONLReporter) ScanError(filename string, err error) {
+	r.write(rdjsonDiagnostic{
+		Message:  fmt.Sprintf("Could not process file %s: %v", filename, err),
+		Location: rdjsonLocation{Path: filename, Range: nil},
+	})
+}
+
+func (r *RDJSONLReporter) NoLinks(_ string) {}
+
+func (r *RDJSONLReporter) ValidLink(_ string, _ link.Link) {}
+
+func (r *RDJSONLReporter) BrokenLink(filename string, brokenLink link.Link, errorType string, _ string) {
+	brokenRule, _ := rule.ForReason(errorType)
+
+	// The source text of the link, which a suggestion replaces
+	urlRange := rdjsonRange{
+		Start: rdjsonPosition{Line: brokenLink.Line, Column: brokenLink.Column},
+		End:   rdjsonPosition{Line: brokenLink.Line, Column: brokenLink.EndColumn},
+	}
+
+	diagnostic := rdjsonDiagnostic{
+		Message:  fmt.Sprintf("broken relative link (%s): %s", errorType, brokenLink.URL),
+		Location: rdjsonLocation{Path: filename, Range: &urlRange},
+		Code:     &rdjsonCode{Value: brokenRule.ID},
+	}
+
+	if isWarning(brokenLink) {
+		diagnostic.Severity = "WARNING"
+	}
+
+	if brokenLink.Fix != "" {
+		diagnostic.Suggestions = []rdjsonSuggestion{{Range: urlRange, Text: brokenLink.Fix}}
+	}
+
+	r.write(diagnostic)
+}
+
+func (r *RDJSONLReporter) ValidLinks(_ string, _ int, _ bool) {}
+
+func (r *RDJSONLReporter) Success() {}
+
+func (r *RDJSONLReporter) Flush() error {
+	return r.err
+}
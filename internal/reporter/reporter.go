@@ -2,83 +2,91 @@ package reporter
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
-	"github.com/anttiharju/relcheck/internal/color"
 	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/rule"
 )
 
-type Reporter struct {
-	Colors     color.Palette
-	Verbose    bool
-	ErrorCount int
+// Reporter receives the findings of a check as they happen
+type Reporter interface {
+	FileNotFound(filename string)
+	ScanError(filename string, err error)
+	NoLinks(filename string)
+	ValidLink(filename string, validLink link.Link)
+	BrokenLink(filename string, brokenLink link.Link, errorType string, lineContent string)
+	ValidLinks(filename string, count int, hasBrokenLinks bool)
+	Success()
+	// Flush writes out anything a format holds back until every file has been checked
+	Flush() error
 }
 
-func New(verbose, forceColors bool) *Reporter {
-	colors := color.GetPalette(forceColors)
-
-	return &Reporter{
-		Colors:  colors,
-		Verbose: verbose,
-	}
+// KnownLinkReporter is implemented by formats that also list the broken links kept in the baseline
+type KnownLinkReporter interface {
+	KnownLink(filename string, knownLink link.Link, errorType string)
 }
 
-func (r *Reporter) FileNotFound(filename string) {
-	fmt.Printf("%sError:%s %sFile not found: %s%s\n",
-		r.Colors.Bold, r.Colors.Reset, r.Colors.Red, r.Colors.Reset, filename)
-
-	r.ErrorCount++
-}
-
-func (r *Reporter) ScanError(filename string, err error) {
-	fmt.Printf("%sError:%s Could not process file %s: %v\n",
-		r.Colors.Bold, r.Colors.Reset, filename, err)
+type Format string
+
+const (
+	Text    Format = "text"
+	JSON    Format = "json"
+	SARIF   Format = "sarif"
+	GitHub  Format = "github"
+	JUnit   Format = "junit"
+	GitLab  Format = "gitlab"
+	RDJSONL Format = "rdjsonl"
+	HTML    Format = "html"
+)
 
-	r.ErrorCount++
+func Formats() []Format {
+	return []Format{Text, JSON, SARIF, GitHub, JUnit, GitLab, RDJSONL, HTML}
 }
 
-func (r *Reporter) NoLinks(filename string) {
-	if r.Verbose {
-		fmt.Printf("%s✓%s %s: %sno relative links%s\n",
-			r.Colors.Green, r.Colors.Reset, filename, r.Colors.Gray, r.Colors.Reset)
+// New creates the reporter of a format. Formats that locate files for another tool, such as GitHub annotations
+// and SARIF, make paths relative to repository, empty if unknown.
+//
+//nolint:ireturn // the format decides the implementation
+func New(format Format, output io.Writer, repository string, verbose, forceColors bool) (Reporter, error) {
+	switch format {
+	case Text:
+		return NewText(output, verbose, forceColors), nil
+	case JSON:
+		return NewJSON(output), nil
+	case SARIF:
+		return NewSARIF(output, repository), nil
+	case GitHub:
+		return NewGitHub(output, os.Getenv("GITHUB_STEP_SUMMARY"), repository, verbose, forceColors), nil
+	case JUnit:
+		return NewJUnit(output), nil
+	case GitLab:
+		return NewGitLab(output), nil
+	case RDJSONL:
+		return NewRDJSONL(output), nil
+	case HTML:
+		return NewHTML(output), nil
 	}
-}
 
-func (r *Reporter) BrokenLink(filename string, brokenLink link.Link, errorType string, lineContent string) {
-	fmt.Printf("%s%s:%d:%d:%s %sbroken relative link (%s):%s\n",
-		r.Colors.Bold, filename, brokenLink.Line, brokenLink.Column,
-		r.Colors.Reset, r.Colors.Red, errorType, r.Colors.Reset)
-
-	fmt.Println(lineContent)
-	fmt.Printf("%s%s%s\n", r.Colors.Yellow, strings.Repeat(" ", brokenLink.Column-1)+"^", r.Colors.Reset)
+	names := make([]string, 0, len(Formats()))
+	for _, known := range Formats() {
+		names = append(names, string(known))
+	}
 
-	r.ErrorCount++
+	return nil, fmt.Errorf("unknown format %q, expected one of: %s", format, strings.Join(names, ", "))
 }
 
-func (r *Reporter) ValidLinks(filename string, count int, hasBrokenLinks bool) {
-	if !r.Verbose || count == 0 {
-		return
-	}
-
-	// Singular/plural formatting
-	countText := "1 valid relative link"
-	if count > 1 {
-		countText = fmt.Sprintf("%d valid relative links", count)
+// DefaultFormat is github within GitHub Actions, so that the action gets annotations without extra setup
+func DefaultFormat() Format {
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		return GitHub
 	}
 
-	// Print with "also has" if there were broken links
-	if !hasBrokenLinks {
-		fmt.Printf("%s✓%s %s: %s\n",
-			r.Colors.Green, r.Colors.Reset, filename, countText)
-	} else {
-		fmt.Printf("%s%s: also has %s%s\n",
-			r.Colors.Gray, filename, countText, r.Colors.Reset)
-	}
+	return Text
 }
 
-func (r *Reporter) Success() {
-	if r.Verbose && r.ErrorCount == 0 {
-		fmt.Printf("%s✓%s %sAll relative links are valid!%s\n",
-			r.Colors.Green, r.Colors.Reset, r.Colors.Bold, r.Colors.Reset)
-	}
+// isWarning reports whether a broken link was configured not to fail the check
+func isWarning(l link.Link) bool {
+	return l.Severity == rule.Warning
 }
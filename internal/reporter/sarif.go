@@ -0,0 +1,297 @@
+package reporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+	"github.com/anttiharju/relcheck/internal/rule"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifLevel   = "error"
+	sarifWarning = "warning"
+
+	sarifSourceRoot = "%SRCROOT%" // The checkout of the repository, as code scanning calls it
+)
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	FullDescription      sarifMessage           `json:"fullDescription"`
+	DefaultConfiguration sarifRuleConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"` // sarifSourceRoot when URI is relative to the repository root
+}
+
+type sarifSnippet struct {
+	Text string `json:"text"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn"`
+	EndColumn   int           `json:"endColumn,omitempty"`
+	Snippet     *sarifSnippet `json:"snippet,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifNotification struct {
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	ColumnKind  string            `json:"columnKind"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// SARIFReporter writes broken links as a SARIF 2.1.0 log for code scanning tools
+type SARIFReporter struct {
+	invocation sarifInvocation
+	results    []sarifResult
+	output     io.Writer
+	repository string // Root that artifact URIs are relative to, empty to keep the paths as given
+}
+
+func NewSARIF(output io.Writer, repository string) *SARIFReporter {
+	return &SARIFReporter{
+		invocation: sarifInvocation{ExecutionSuccessful: true, ToolExecutionNotifications: nil},
+		results:    []sarifResult{},
+		output:     output,
+		repository: repository,
+	}
+}
+
+func (r *SARIFReporter) notify(filename, message string) {
+	r.invocation.ExecutionSuccessful = false
+	r.invocation.ToolExecutionNotifications = append(r.invocation.ToolExecutionNotifications, sarifNotification{
+		Level:   sarifLevel,
+		Message: sarifMessage{Text: message},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: r.artifactLocation(filename),
+				Region:           sarifRegion{StartLine: 1, StartColumn: 1, EndColumn: 0, Snippet: nil},
+			},
+		}},
+	})
+}
+
+func (r *SARIFReporter) FileNotFound(filename string) {
+	r.notify(filename, "File not found: "+filename)
+}
+
+func (r *SARIFReporter) ScanError(filename string, err error) {
+	r.notify(filename, fmt.Sprintf("Could not process file %s: %v", filename, err))
+}
+
+func (r *SARIFReporter) NoLinks(_ string) {}
+
+func (r *SARIFReporter) ValidLink(_ string, _ link.Link) {}
+
+func (r *SARIFReporter) BrokenLink(filename string, brokenLink link.Link, errorType string, lineContent string) {
+	brokenRule, _ := rule.ForReason(errorType)
+	region := linkRegion(brokenLink, lineContent)
+
+	level := sarifLevel
+	if isWarning(brokenLink) {
+		level = sarifWarning
+	}
+
+	result := sarifResult{
+		RuleID:    brokenRule.ID,
+		RuleIndex: ruleIndex(brokenRule),
+		Level:     level,
+		Message:   sarifMessage{Text: fmt.Sprintf("broken relative link (%s): %s", errorType, brokenLink.URL)},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: r.artifactLocation(filename),
+				Region:           region,
+			},
+		}},
+		Fixes: nil,
+	}
+
+	if brokenLink.Fix != "" {
+		region.Snippet = nil
+		result.Fixes = []sarifFix{{
+			Description: sarifMessage{Text: fmt.Sprintf("Change the link to %s", brokenLink.Fix)},
+			ArtifactChanges: []sarifArtifactChange{{
+				ArtifactLocation: r.artifactLocation(filename),
+				Replacements: []sarifReplacement{{
+					DeletedRegion:   region,
+					InsertedContent: sarifMessage{Text: brokenLink.Fix},
+				}},
+			}},
+		}}
+	}
+
+	r.results = append(r.results, result)
+}
+
+func (r *SARIFReporter) ValidLinks(_ string, _ int, _ bool) {}
+
+func (r *SARIFReporter) Success() {}
+
+func (r *SARIFReporter) Flush() error {
+	rules := make([]sarifRule, 0, len(rule.All()))
+	for _, known := range rule.All() {
+		rules = append(rules, sarifRule{
+			ID:                   known.ID,
+			Name:                 known.Name,
+			ShortDescription:     sarifMessage{Text: known.Reason},
+			FullDescription:      sarifMessage{Text: known.Description},
+			DefaultConfiguration: sarifRuleConfiguration{Level: sarifLevel},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "relcheck",
+				InformationURI: "https://github.com/anttiharju/relcheck",
+				Rules:          rules,
+			}},
+			ColumnKind:  "unicodeCodePoints",
+			Invocations: []sarifInvocation{r.invocation},
+			Results:     r.results,
+		}},
+	}
+
+	encoder := json.NewEncoder(r.output)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(log); err != nil {
+		return fmt.Errorf("failed to write SARIF report: %w", err)
+	}
+
+	return nil
+}
+
+func ruleIndex(brokenRule rule.Rule) int {
+	for i, known := range rule.All() {
+		if known.ID == brokenRule.ID {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// linkRegion covers the source text of a link, with columns counted in code points rather than bytes
+func linkRegion(l link.Link, lineContent string) sarifRegion {
+	startColumn := codePointColumn(lineContent, l.Column)
+	endColumn := codePointColumn(lineContent, l.EndColumn)
+
+	return sarifRegion{
+		StartLine:   l.Line,
+		StartColumn: startColumn,
+		EndColumn:   endColumn,
+		Snippet:     &sarifSnippet{Text: lineContent},
+	}
+}
+
+func codePointColumn(line string, byteColumn int) int {
+	if byteColumn-1 > len(line) {
+		return byteColumn
+	}
+
+	return utf8.RuneCountInString(line[:byteColumn-1]) + 1
+}
+
+// artifactLocation refers to a file relative to the repository root, which code scanning resolves %SRCROOT% to,
+// so that results land on the right files wherever relcheck ran
+func (r *SARIFReporter) artifactLocation(filename string) sarifArtifactLocation {
+	path, ok := repositoryPath(r.repository, filename)
+
+	baseID := ""
+	if ok {
+		baseID = sarifSourceRoot
+	}
+
+	return sarifArtifactLocation{URI: fileURI(path), URIBaseID: baseID}
+}
+
+// fileURI turns a file path into a relative URI reference, e.g. issues%20caught.markdown
+func fileURI(filename string) string {
+	uri := url.URL{Path: filepath.ToSlash(filename)}
+
+	return uri.EscapedPath()
+}
@@ -0,0 +1,99 @@
+package reporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/anttiharju/relcheck/internal/color"
+	"github.com/anttiharju/relcheck/internal/markdown/link"
+)
+
+// TextReporter writes human-readable, optionally colored findings
+type TextReporter struct {
+	Colors     color.Palette
+	Verbose    bool
+	ErrorCount int
+	output     io.Writer
+}
+
+func NewText(output io.Writer, verbose, forceColors bool) *TextReporter {
+	colors := color.GetPalette(output, forceColors)
+
+	return &TextReporter{
+		Colors:  colors,
+		Verbose: verbose,
+		output:  output,
+	}
+}
+
+func (r *TextReporter) FileNotFound(filename string) {
+	fmt.Fprintf(r.output, "%sError:%s %sFile not found: %s%s\n",
+		r.Colors.Bold, r.Colors.Reset, r.Colors.Red, r.Colors.Reset, filename)
+
+	r.ErrorCount++
+}
+
+func (r *TextReporter) ScanError(filename string, err error) {
+	fmt.Fprintf(r.output, "%sError:%s Could not process file %s: %v\n",
+		r.Colors.Bold, r.Colors.Reset, filename, err)
+
+	r.ErrorCount++
+}
+
+func (r *TextReporter) NoLinks(filename string) {
+	if r.Verbose {
+		fmt.Fprintf(r.output, "%s✓%s %s: %sno relative links%s\n",
+			r.Colors.Green, r.Colors.Reset, filename, r.Colors.Gray, r.Colors.Reset)
+	}
+}
+
+func (r *TextReporter) BrokenLink(filename string, brokenLink link.Link, errorType string, lineContent string) {
+	label, labelColor := "broken relative link", r.Colors.Red
+	if isWarning(brokenLink) {
+		label, labelColor = "warning: broken relative link", r.Colors.Yellow
+	}
+
+	fmt.Fprintf(r.output, "%s%s:%d:%d:%s %s%s (%s):%s\n",
+		r.Colors.Bold, filename, brokenLink.Line, brokenLink.Column,
+		r.Colors.Reset, labelColor, label, errorType, r.Colors.Reset)
+
+	fmt.Fprintln(r.output, lineContent)
+	fmt.Fprintf(r.output, "%s%s%s\n", r.Colors.Yellow, strings.Repeat(" ", brokenLink.Column-1)+"^", r.Colors.Reset)
+
+	r.ErrorCount++
+}
+
+func (r *TextReporter) ValidLink(_ string, _ link.Link) {}
+
+func (r *TextReporter) ValidLinks(filename string, count int, hasBrokenLinks bool) {
+	if !r.Verbose || count == 0 {
+		return
+	}
+
+	// Singular/plural formatting
+	countText := "1 valid relative link"
+	if count > 1 {
+		countText = fmt.Sprintf("%d valid relative links", count)
+	}
+
+	// Print with "also has" if there were broken links
+	if !hasBrokenLinks {
+		fmt.Fprintf(r.output, "%s✓%s %s: %s\n",
+			r.Colors.Green, r.Colors.Reset, filename, countText)
+	} else {
+		fmt.Fprintf(r.output, "%s%s: also has %s%s\n",
+			r.Colors.Gray, filename, countText, r.Colors.Reset)
+	}
+}
+
+func (r *TextReporter) Success() {
+	if r.Verbose && r.ErrorCount == 0 {
+		fmt.Fprintf(r.output, "%s✓%s %sAll relative links are valid!%s\n",
+			r.Colors.Green, r.Colors.Reset, r.Colors.Bold, r.Colors.Reset)
+	}
+}
+
+func (r *TextReporter) Flush() error {
+	return nil
+}
@@ -0,0 +1,127 @@
+// Package rule gives every reason for a broken link a stable identifier for machine-readable reports.
+package rule
+
+import "fmt"
+
+type Rule struct {
+	ID          string // Stable, never reused
+	Name        string
+	Reason      string // As shown in text output, e.g. "target not found"
+	Description string
+}
+
+//nolint:gochecknoglobals
+var (
+	RootNotFound = Rule{
+		ID:     "RC001",
+		Name:   "root-not-found",
+		Reason: "repository root not found",
+		Description: "Root-relative links such as /docs/setup.md need a repository root, " +
+			"pass --root outside of Git repositories.",
+	}
+	TargetNotFound = Rule{
+		ID:          "RC002",
+		Name:        "target-not-found",
+		Reason:      "target not found",
+		Description: "The file or directory a relative link points to does not exist.",
+	}
+	DirectoryAnchor = Rule{
+		ID:          "RC003",
+		Name:        "directory-anchor",
+		Reason:      "cannot refer to a heading of a directory",
+		Description: "A link to a directory cannot have an anchor, as directories have no headings.",
+	}
+	InvalidLineNumber = Rule{
+		ID:          "RC004",
+		Name:        "invalid-line-number",
+		Reason:      "invalid line number",
+		Description: "A line anchor such as #L5 does not contain a valid line number.",
+	}
+	LineOutOfRange = Rule{
+		ID:          "RC005",
+		Name:        "line-out-of-range",
+		Reason:      "line number out of range",
+		Description: "A line anchor such as #L5 refers to a line the target file does not have.",
+	}
+	HeadingNotFound = Rule{
+		ID:          "RC006",
+		Name:        "heading-not-found",
+		Reason:      "heading not found",
+		Description: "No heading or explicit anchor of the target file matches the anchor of the link.",
+	}
+	UndefinedReference = Rule{
+		ID:          "RC007",
+		Name:        "undefined-reference",
+		Reason:      "reference definition not found",
+		Description: "A reference link such as [text][label] has no matching [label]: definition.",
+	}
+	UnusedDefinition = Rule{
+		ID:     "RC008",
+		Name:   "unused-definition",
+		Reason: "unused reference definition",
+		Description: "A link reference definition is never referred to, " +
+			"or is shadowed by an earlier one with the same label.",
+	}
+	UnusedSuppression = Rule{
+		ID:     "RC009",
+		Name:   "unused-suppression",
+		Reason: "unused suppression comment",
+		Description: "A relcheck-disable comment no longer suppresses any broken link " +
+			"and can be removed.",
+	}
+	UnknownRule = Rule{
+		ID:     "RC010",
+		Name:   "unknown-rule",
+		Reason: "unknown rule",
+		Description: "A relcheck-disable or relcheck-enable comment names a rule that does not exist, " +
+			"such as a misspelt one, and does not apply to it.",
+	}
+)
+
+func All() []Rule {
+	return []Rule{
+		RootNotFound, TargetNotFound, DirectoryAnchor, InvalidLineNumber,
+		LineOutOfRange, HeadingNotFound, UndefinedReference, UnusedDefinition,
+		UnusedSuppression, UnknownRule,
+	}
+}
+
+// Lookup finds a rule by ID or by name, like RC008 or unused-definition
+func Lookup(key string) (Rule, bool) {
+	for _, rule := range All() {
+		if rule.ID == key || rule.Name == key {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// ForReason finds the rule of a reason as passed to reporters
+func ForReason(reason string) (Rule, bool) {
+	for _, rule := range All() {
+		if rule.Reason == reason {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// Severity decides whether a broken link fails the check
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning" // Reported, but does not fail the check
+	Off     Severity = "off"     // Not checked at all
+)
+
+func ParseSeverity(s string) (Severity, error) {
+	switch severity := Severity(s); severity {
+	case Error, Warning, Off:
+		return severity, nil
+	}
+
+	return "", fmt.Errorf("unknown severity %q, expected error, warning or off", s)
+}
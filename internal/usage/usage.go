@@ -7,9 +7,29 @@ import (
 )
 
 func Print() exitcode.Exitcode {
-	fmt.Println("Usage: relcheck [--verbose] [--color=always] <file1.md> [file2.md] ...")
-	fmt.Println("   or: relcheck [--verbose] [--color=always] all  (to check all *.md files tracked by Git)")
+	fmt.Println("Usage: relcheck [options] <file1.md> [file2.md] ...")
+	fmt.Println("   or: relcheck [options] all  (to check all Markdown files tracked by Git)")
+	fmt.Println("   or: relcheck [options] baseline [files]  (to record broken links that should not fail the check)")
+	fmt.Println("   or: relcheck [options] watch [files]  (to check again whenever files change)")
+	fmt.Println("   or: relcheck [options] lsp  (to serve diagnostics to editors over the Language Server Protocol)")
+	fmt.Println("   or: relcheck [options] rename-anchor <file> <anchor> <new heading>  (to rename a heading and update the links to it)")
+	fmt.Println("   or: relcheck [options] mv <source> <destination>  (to move files with Git and update the links to and within them)")
 	fmt.Println("   or: relcheck version  (to show version information)")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  --verbose                also list valid files")
+	fmt.Println("  --color=always           use colors even when not writing to a terminal")
+	fmt.Println("  -C, --directory <dir>    run as if started in <dir>")
+	fmt.Println("  --root <dir>             resolve links such as /docs/setup.md against <dir>, defaults to the Git repository root")
+	fmt.Println("  --anchor-style <style>   github (default), gitlab, mkdocs, hugo, docusaurus or azure-devops")
+	fmt.Println("  --format <format>        text, json, sarif, github, junit, gitlab, rdjsonl or html, defaults to github when GITHUB_ACTIONS=true and text otherwise")
+	fmt.Println("  --output <file>          write the report to <file> instead of stdout")
+	fmt.Println("  --config <file>          read settings from <file>, defaults to the nearest .relcheck.yml upwards")
+	fmt.Println("  --baseline <file>        known broken links, defaults to .relcheck-baseline.json in the Git repository root")
+	fmt.Println("  --jobs <n>               check <n> files in parallel, defaults to the number of CPUs")
+	fmt.Println("  --cache-dir <dir>        keep scan results in <dir> between runs, dropping those unused for a week, off by default")
+	fmt.Println("  --no-cache               scan every file again, even with cache-dir set in the configuration file")
+	fmt.Println("  --dry-run                print the changes of rename-anchor and mv as a diff instead of making them")
 
 	return exitcode.UsageError
 }
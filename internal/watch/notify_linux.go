@@ -0,0 +1,130 @@
+//go:build linux
+
+package watch
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+type inotify struct {
+	fd      int
+	file    *os.File       // For reads that stop when it is closed
+	dirs    map[int]string // By watch descriptor
+	root    string
+	changes chan<- string
+}
+
+func notify(ctx context.Context, dir string, changes chan<- string) error {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("failed to start inotify: %w", err)
+	}
+
+	watcher := &inotify{
+		fd:      fd,
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		dirs:    make(map[int]string),
+		root:    dir,
+		changes: changes,
+	}
+
+	if err := watcher.addTree(dir); err != nil {
+		watcher.file.Close()
+
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		watcher.file.Close()
+	}()
+
+	go watcher.read(ctx)
+
+	return nil
+}
+
+// addTree watches dir and the directories below it, as inotify is not recursive
+func (w *inotify) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil //nolint:nilerr // Directories may vanish while walking
+		}
+
+		if path != dir && skipDir(entry.Name()) {
+			return filepath.SkipDir
+		}
+
+		wd, err := syscall.InotifyAddWatch(w.fd, path, inotifyMask)
+		if err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+
+		w.dirs[wd] = path
+
+		return nil
+	})
+}
+
+func (w *inotify) read(ctx context.Context) {
+	defer close(w.changes)
+
+	buffer := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+
+	for {
+		n, err := w.file.Read(buffer)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			// struct inotify_event: wd, mask, cookie, len, then a NUL-padded name of len bytes
+			wd := int(binary.NativeEndian.Uint32(buffer[offset:]))
+			mask := binary.NativeEndian.Uint32(buffer[offset+4:])
+			nameLength := int(binary.NativeEndian.Uint32(buffer[offset+12:]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			name := strings.TrimRight(string(buffer[nameStart:nameStart+nameLength]), "\x00")
+			offset = nameStart + nameLength
+
+			if !w.handle(ctx, wd, mask, name) {
+				return
+			}
+		}
+	}
+}
+
+func (w *inotify) handle(ctx context.Context, wd int, mask uint32, name string) bool {
+	switch {
+	case mask&syscall.IN_Q_OVERFLOW != 0:
+		// Events were lost, so anything may have changed
+		return send(ctx, w.changes, w.root)
+	case mask&syscall.IN_IGNORED != 0:
+		delete(w.dirs, wd)
+
+		return true
+	}
+
+	dir, ok := w.dirs[wd]
+	if !ok {
+		return true
+	}
+
+	path := filepath.Join(dir, name)
+
+	// New directories are watched too, a change to a directory stands for the files within it
+	if mask&syscall.IN_ISDIR != 0 && mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 && !skipDir(name) {
+		_ = w.addTree(path)
+	}
+
+	return send(ctx, w.changes, path)
+}
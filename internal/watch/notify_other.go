@@ -0,0 +1,13 @@
+//go:build !linux
+
+package watch
+
+import (
+	"context"
+	"errors"
+)
+
+// notify is only implemented with inotify, other systems are polled
+func notify(_ context.Context, _ string, _ chan<- string) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,75 @@
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
+const pollInterval = 500 * time.Millisecond
+
+type fileState struct {
+	size    int64
+	modTime int64
+}
+
+// poll compares the files below dir every pollInterval, for systems without inotify
+func poll(ctx context.Context, dir string, changes chan<- string) {
+	defer close(changes)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	known := snapshot(dir)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := snapshot(dir)
+
+		for path, state := range current {
+			if previous, ok := known[path]; (!ok || previous != state) && !send(ctx, changes, path) {
+				return
+			}
+		}
+
+		for path := range known {
+			if _, ok := current[path]; !ok && !send(ctx, changes, path) {
+				return
+			}
+		}
+
+		known = current
+	}
+}
+
+func snapshot(dir string) map[string]fileState {
+	files := make(map[string]fileState)
+
+	_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // Files may vanish while walking
+		}
+
+		if entry.IsDir() {
+			if path != dir && skipDir(entry.Name()) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if info, err := entry.Info(); err == nil {
+			files[path] = fileState{size: info.Size(), modTime: info.ModTime().UnixNano()}
+		}
+
+		return nil
+	})
+
+	return files
+}
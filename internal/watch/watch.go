@@ -0,0 +1,39 @@
+// Package watch reports changes to files below a directory, through inotify on Linux and by polling elsewhere.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// Changes sends the absolute paths of files created, modified or removed below dir, until ctx is done.
+// A directory is sent when everything below it may have changed.
+func Changes(ctx context.Context, dir string) (<-chan string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	changes := make(chan string)
+
+	if err := notify(ctx, dir, changes); err != nil {
+		go poll(ctx, dir, changes)
+	}
+
+	return changes, nil
+}
+
+// skipDir tells whether a directory is too busy or irrelevant to watch
+func skipDir(name string) bool {
+	return name == ".git" || name == "node_modules"
+}
+
+func send(ctx context.Context, changes chan<- string, path string) bool {
+	select {
+	case changes <- path:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -6,7 +6,6 @@ import (
 
 	"github.com/anttiharju/relcheck/internal/buildinfo"
 	"github.com/anttiharju/relcheck/internal/cli"
-	"github.com/anttiharju/relcheck/internal/exitcode"
 	"github.com/anttiharju/relcheck/internal/interrupt"
 )
 
@@ -17,9 +16,11 @@ var (
 )
 
 func main() {
-	go interrupt.Listen(exitcode.Interrupt, os.Interrupt)
+	ctx, stop := interrupt.Context(context.Background(), os.Interrupt)
 
-	ctx := context.Background()
 	exitCode := cli.Start(ctx, buildinfo.New(revision, version, time), os.Args[1:])
+	exitCode = interrupt.Exitcode(ctx, exitCode)
+
+	stop()
 	os.Exit(int(exitCode))
 }
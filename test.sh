@@ -9,7 +9,7 @@ echo 0 > "$tmp_exit_code"
 
 (
     cd docs/examples || exit
-    ../../relcheck all --verbose --color=always > ../../tests/got/valid-use
+    ../../relcheck all --verbose --color=always --format=text > ../../tests/got/valid-use
     cd ../../tests || exit
 
     if [ "$1" = "--regenerate" ]; then
@@ -24,7 +24,7 @@ echo 0 > "$tmp_exit_code"
 (
     cd docs/examples || exit
     files=$(git ls-files '*.markdown')
-    ../../relcheck --verbose --color=always "$files" > "../../tests/got/issues caught"
+    ../../relcheck --verbose --color=always --format=text "$files" > "../../tests/got/issues caught"
     cd ../../tests || exit
 
     if [ "$1" = "--regenerate" ]; then
@@ -36,6 +36,226 @@ echo 0 > "$tmp_exit_code"
     fi
 )
 
+(
+    cd docs/examples || exit
+    files=$(git ls-files '*.markdown')
+    ../../relcheck --format=json "$files" > "../../tests/got/issues caught.json"
+    cd ../../tests || exit
+
+    if [ "$1" = "--regenerate" ]; then
+        cp "got/issues caught.json" "want/issues caught.json"
+    else
+        if ! diff --color -u "want/issues caught.json" "got/issues caught.json"; then
+            echo 1 > "$tmp_exit_code"
+        fi
+    fi
+)
+
+(
+    cd docs/examples || exit
+    files=$(git ls-files '*.markdown')
+    ../../relcheck --format=sarif "$files" > "../../tests/got/issues caught.sarif"
+    cd ../../tests || exit
+
+    if [ "$1" = "--regenerate" ]; then
+        cp "got/issues caught.sarif" "want/issues caught.sarif"
+    else
+        if ! diff --color -u "want/issues caught.sarif" "got/issues caught.sarif"; then
+            echo 1 > "$tmp_exit_code"
+        fi
+    fi
+)
+
+(
+    cd docs/examples || exit
+    files=$(git ls-files '*.markdown')
+    GITHUB_STEP_SUMMARY="" ../../relcheck --format=github "$files" > "../../tests/got/issues caught.github"
+    cd ../../tests || exit
+
+    if [ "$1" = "--regenerate" ]; then
+        cp "got/issues caught.github" "want/issues caught.github"
+    else
+        if ! diff --color -u "want/issues caught.github" "got/issues caught.github"; then
+            echo 1 > "$tmp_exit_code"
+        fi
+    fi
+)
+
+(
+    cd docs/examples || exit
+    files=$(git ls-files '*.markdown')
+    ../../relcheck --format=junit --output="../../tests/got/issues caught.xml" "$files"
+    cd ../../tests || exit
+
+    if [ "$1" = "--regenerate" ]; then
+        cp "got/issues caught.xml" "want/issues caught.xml"
+    else
+        if ! diff --color -u "want/issues caught.xml" "got/issues caught.xml"; then
+            echo 1 > "$tmp_exit_code"
+        fi
+    fi
+)
+
+(
+    cd docs/examples || exit
+    files=$(git ls-files '*.markdown')
+    ../../relcheck --format=gitlab "$files" > "../../tests/got/issues caught.gitlab"
+    cd ../../tests || exit
+
+    if [ "$1" = "--regenerate" ]; then
+        cp "got/issues caught.gitlab" "want/issues caught.gitlab"
+    else
+        if ! diff --color -u "want/issues caught.gitlab" "got/issues caught.gitlab"; then
+            echo 1 > "$tmp_exit_code"
+        fi
+    fi
+)
+
+(
+    cd docs/examples || exit
+    files=$(git ls-files '*.markdown')
+    ../../relcheck --format=rdjsonl "$files" > "../../tests/got/issues caught.rdjsonl"
+    cd ../../tests || exit
+
+    if [ "$1" = "--regenerate" ]; then
+        cp "got/issues caught.rdjsonl" "want/issues caught.rdjsonl"
+    else
+        if ! diff --color -u "want/issues caught.rdjsonl" "got/issues caught.rdjsonl"; then
+            echo 1 > "$tmp_exit_code"
+        fi
+    fi
+)
+
+(
+    cd docs/examples || exit
+    files=$(git ls-files '*.markdown')
+    ../../relcheck --format=html "$files" > "../../tests/got/issues caught.html"
+    cd ../../tests || exit
+
+    if [ "$1" = "--regenerate" ]; then
+        cp "got/issues caught.html" "want/issues caught.html"
+    else
+        if ! diff --color -u "want/issues caught.html" "got/issues caught.html"; then
+            echo 1 > "$tmp_exit_code"
+        fi
+    fi
+)
+
+(
+    cd docs/examples || exit
+    files=$(git ls-files '*.markdown')
+    ../../relcheck --baseline "../../tests/got/issues caught.baseline.json" baseline "$files" > /dev/null
+
+    # Everything broken is in the baseline, so the check passes
+    if ! ../../relcheck --baseline "../../tests/got/issues caught.baseline.json" --format=json "$files" > "../../tests/got/issues caught.known.json"; then
+        echo 1 > "$tmp_exit_code"
+    fi
+
+    # Entries are relative to the baseline file, so that it works wherever relcheck runs
+    if ! ../../relcheck -C ../.. --baseline "../../tests/got/issues caught.baseline.json" --format=text "docs/examples/issues caught.markdown" > /dev/null; then
+        echo 1 > "$tmp_exit_code"
+    fi
+    cd ../../tests || exit
+
+    for report in "issues caught.baseline.json" "issues caught.known.json"; do
+        if [ "$1" = "--regenerate" ]; then
+            cp "got/$report" "want/$report"
+        else
+            if ! diff --color -u "want/$report" "got/$report"; then
+                echo 1 > "$tmp_exit_code"
+            fi
+        fi
+    done
+)
+
+(
+    cd docs/examples || exit
+    ../../relcheck --dry-run mv valid-use.md guides/valid-use.md > "../../tests/got/valid-use.mv.diff"
+    ../../relcheck --dry-run rename-anchor valid-use.md links "Relative links" > "../../tests/got/valid-use.rename-anchor.diff"
+    cd ../../tests || exit
+
+    for diff in valid-use.mv.diff valid-use.rename-anchor.diff; do
+        if [ "$1" = "--regenerate" ]; then
+            cp "got/$diff" "want/$diff"
+        else
+            if ! diff --color -u "want/$diff" "got/$diff"; then
+                echo 1 > "$tmp_exit_code"
+            fi
+        fi
+    done
+)
+
+(
+    cd tests/configured || exit
+    ../../relcheck all --verbose --color=always --format=text > ../got/configured
+    cd .. || exit
+
+    if [ "$1" = "--regenerate" ]; then
+        cp got/configured want/configured
+    else
+        if ! diff --color -u want/configured got/configured; then
+            echo 1 > "$tmp_exit_code"
+        fi
+    fi
+)
+
+(
+    cd tests/refactor || exit
+    {
+        ../../relcheck --dry-run rename-anchor guide.md quoted-heading "Quoted title"
+        ../../relcheck --dry-run rename-anchor guide.md listed-heading "Listed title"
+        ../../relcheck --dry-run rename-anchor guide.md quoted-setext "Setext title"
+    } > ../got/refactor.rename-anchor.diff
+
+    # Into an existing subdirectory, given by an absolute path like editors and shell completion do
+    ../../relcheck --dry-run mv "$PWD/index.md" guides/ > ../got/refactor.mv.diff
+    cd .. || exit
+
+    for diff in refactor.rename-anchor.diff refactor.mv.diff; do
+        if [ "$1" = "--regenerate" ]; then
+            cp "got/$diff" "want/$diff"
+        else
+            if ! diff --color -u "want/$diff" "got/$diff"; then
+                echo 1 > "$tmp_exit_code"
+            fi
+        fi
+    done
+)
+
+(
+    cd tests/baseline || exit
+    ../../relcheck --baseline ../got/subset.baseline.json baseline all > /dev/null
+
+    # Recording a subset of the files keeps the entries of the others
+    ../../relcheck --baseline ../got/subset.baseline.json baseline c.markdown > /dev/null
+    if ! ../../relcheck --baseline ../got/subset.baseline.json all > /dev/null; then
+        echo 1 > "$tmp_exit_code"
+    fi
+    cd .. || exit
+
+    if [ "$1" = "--regenerate" ]; then
+        cp got/subset.baseline.json want/subset.baseline.json
+    else
+        if ! diff --color -u want/subset.baseline.json got/subset.baseline.json; then
+            echo 1 > "$tmp_exit_code"
+        fi
+    fi
+)
+
+(
+    # Files are checked in parallel, yet reported as if one after the other
+    cd docs/examples || exit
+    for jobs in 1 8; do
+        git ls-files -z '*.md' '*.markdown' |
+            xargs -0 ../../relcheck --jobs "$jobs" --verbose --format=text > "../../tests/got/jobs-$jobs"
+    done
+    cd ../../tests || exit
+
+    if ! diff --color -u got/jobs-1 got/jobs-8; then
+        echo 1 > "$tmp_exit_code"
+    fi
+)
+
 exit_code=$(cat "$tmp_exit_code")
 
 exit "$exit_code"